@@ -0,0 +1,30 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes a file through a temporary file in the same directory, so readers never
+// see a partially written file.
+func WriteFileAtomic(path string, write func(*os.File) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	// Once renamed, the temporary file is already gone.
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hibare/Waypoint/cmd/common"
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/spf13/cobra"
+)
+
+var (
+	countries string
+	asns      string
+	format    string
+	name      string
+	out       string
+)
+
+var aclCmd = &cobra.Command{
+	Use:   "acl",
+	Short: "Generate a firewall or proxy access control list",
+	Long: "Generate an access control list of the networks located in the given countries or announced by the given ASNs, " +
+		"aggregated into the smallest set of CIDRs, in a format ready to load into a firewall or proxy.",
+	Example: "  waypoint export acl --country DE,FR --asn 13335 --format nginx-geo --out /etc/nginx/geo.conf",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		aclFormat, err := maxmind.ParseACLFormat(format)
+		if err != nil {
+			return err
+		}
+		if err := maxmind.ValidateACLName(name); err != nil {
+			return err
+		}
+		var filter maxmind.ACLFilter
+		if filter.Countries, err = maxmind.ParseCountryCodes(countries); err != nil {
+			return err
+		}
+		if filter.ASNs, err = maxmind.ParseASNs(asns); err != nil {
+			return err
+		}
+
+		mmClient := maxmind.NewClient(&config.Current.MaxMind, config.Current.Core.DataDir)
+		if err := mmClient.Load(); err != nil {
+			return fmt.Errorf("failed to load databases: %w", err)
+		}
+		defer mmClient.Close()
+
+		networks, err := mmClient.ACLNetworks(filter)
+		if err != nil {
+			return err
+		}
+
+		if out == "" {
+			return maxmind.WriteACL(cmd.OutOrStdout(), aclFormat, name, networks)
+		}
+		if err := common.WriteFileAtomic(out, func(f *os.File) error { return maxmind.WriteACL(f, aclFormat, name, networks) }); err != nil {
+			return err
+		}
+		cmd.PrintErrf("Wrote %d networks to %s\n", len(networks), out)
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+func init() {
+	formats := make([]string, 0, len(maxmind.ACLFormats))
+	for _, f := range maxmind.ACLFormats {
+		formats = append(formats, string(f))
+	}
+
+	aclCmd.Flags().StringVar(&countries, "country", "", "Comma-separated ISO 3166-1 alpha-2 country codes")
+	aclCmd.Flags().StringVar(&asns, "asn", "", "Comma-separated ASNs, such as 13335 or AS13335")
+	aclCmd.Flags().StringVar(&format, "format", string(maxmind.ACLFormatPlainCIDR),
+		fmt.Sprintf("Output format (supported: %s)", strings.Join(formats, ", ")))
+	aclCmd.Flags().StringVar(&name, "name", maxmind.DefaultACLName, "Name of the generated sets, variables and matchers")
+	aclCmd.Flags().StringVarP(&out, "out", "o", "", "File to write, replaced atomically (default: standard output)")
+}
@@ -0,0 +1,16 @@
+package export
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var ExportCmd = &cobra.Command{
+	Use:          "export",
+	Short:        "Export geolocation data",
+	Long:         "Generate files from the loaded geolocation databases for use by other software.",
+	SilenceUsage: true,
+}
+
+func init() {
+	ExportCmd.AddCommand(aclCmd)
+}
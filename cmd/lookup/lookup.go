@@ -3,31 +3,53 @@ package lookup
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/hibare/Waypoint/internal/config"
 	"github.com/hibare/Waypoint/internal/maxmind"
 	"github.com/spf13/cobra"
 )
 
+var (
+	lang   string
+	fields string
+)
+
 var LookupCmd = &cobra.Command{
 	Use:   "lookup <ip>",
 	Short: "Lookup IP geolocation information",
-	Long:  "Lookup geographic information for a given IP address using MaxMind GeoIP databases. Supports both IPv4 and IPv6 addresses.",
+	Long:  "Lookup geographic information for a given IP address using the configured provider databases. Supports both IPv4 and IPv6 addresses.",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ip := args[0]
 
-		mmClient := maxmind.NewClient(&config.Current.MaxMind, config.Current.Core.DataDir)
-		if err := mmClient.Load(); err != nil {
-			return fmt.Errorf("failed to load MaxMind databases: %w", err)
+		selected, err := maxmind.ParseFields(fields)
+		if err != nil {
+			return err
 		}
 
-		record, err := mmClient.IP2Geo(ip)
+		provider, err := maxmind.NewProvider(config.Current)
+		if err != nil {
+			return err
+		}
+		if err := provider.Load(); err != nil {
+			return fmt.Errorf("failed to load databases: %w", err)
+		}
+		defer provider.Close()
+
+		record, err := provider.IP2Geo(ip, maxmind.WithLanguage(maxmind.NegotiateLanguage(lang, "")), maxmind.WithFields(selected...))
 		if err != nil {
 			return fmt.Errorf("error fetching record: %w", err)
 		}
 
-		b, err := json.MarshalIndent(record, "", "    ")
+		var output any = record
+		if len(selected) > 0 {
+			if output, err = maxmind.SelectFields(record, selected); err != nil {
+				return fmt.Errorf("error parsing record: %w", err)
+			}
+		}
+
+		b, err := json.MarshalIndent(output, "", "    ")
 		if err != nil {
 			return fmt.Errorf("error parsing record: %w", err)
 		}
@@ -37,3 +59,10 @@ var LookupCmd = &cobra.Command{
 	},
 	SilenceUsage: true,
 }
+
+func init() {
+	LookupCmd.Flags().StringVar(&lang, "lang", maxmind.DefaultLanguage,
+		fmt.Sprintf("Language for place names, falls back to English (supported: %s)", strings.Join(maxmind.SupportedLanguages, ", ")))
+	LookupCmd.Flags().StringVar(&fields, "fields", "",
+		"Comma-separated fields to print, databases answering none of them are not queried (default: all fields)")
+}
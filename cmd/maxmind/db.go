@@ -13,4 +13,10 @@ var MaxmindCmd = &cobra.Command{
 
 func init() {
 	MaxmindCmd.AddCommand(downloadCmd)
+	MaxmindCmd.AddCommand(infoCmd)
+	MaxmindCmd.AddCommand(importCmd)
+	MaxmindCmd.AddCommand(versionsCmd)
+	MaxmindCmd.AddCommand(rollbackCmd)
+	MaxmindCmd.AddCommand(exportCmd)
+	MaxmindCmd.AddCommand(diffCmd)
 }
@@ -0,0 +1,52 @@
+package maxmind
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hibare/Waypoint/cmd/common"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/spf13/cobra"
+)
+
+var diffDetail string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old.mmdb> <new.mmdb>",
+	Short: "Compare two versions of a database",
+	Long: "Report the networks that were added, removed or whose country, city or ASN changed between two versions of a database, " +
+		"summarized by country and ASN. Every differing network can also be written to an NDJSON file.",
+	Example: "  waypoint maxmind diff data/versions/GeoLite2-City/1700000000.mmdb data/GeoLite2-City.mmdb --detail changes.ndjson",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var report *maxmind.DiffReport
+		diff := func(detail io.Writer) error {
+			var err error
+			report, err = maxmind.Diff(args[0], args[1], detail)
+			return err
+		}
+
+		if diffDetail == "" {
+			if err := diff(nil); err != nil {
+				return err
+			}
+		} else if err := common.WriteFileAtomic(diffDetail, func(f *os.File) error { return diff(f) }); err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return fmt.Errorf("error parsing diff: %w", err)
+		}
+
+		cmd.Println(string(b))
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffDetail, "detail", "", "NDJSON file to write every differing network to, replaced atomically")
+}
@@ -0,0 +1,82 @@
+package maxmind
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hibare/Waypoint/cmd/common"
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat    string
+	exportIPVersion int
+	exportLang      string
+	exportOut       string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <edition>",
+	Short: "Export every network of a database",
+	Long: "Stream every network of a loaded database with the fields the edition answers, flattened in the shape of the lookup response, " +
+		"for loading into a data warehouse. CSV files have a header row, and nested fields such as subdivisions are written as JSON.",
+	Example: "  waypoint maxmind export GeoLite2-City --format csv --ip-version 4 --out city.csv",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := maxmind.ParseExportFormat(exportFormat)
+		if err != nil {
+			return err
+		}
+		if exportIPVersion != 0 && exportIPVersion != 4 && exportIPVersion != 6 {
+			return fmt.Errorf("--ip-version must be 4 or 6, got %d", exportIPVersion)
+		}
+
+		mmClient := maxmind.NewClient(&config.Current.MaxMind, config.Current.Core.DataDir)
+		if err := mmClient.Load(); err != nil {
+			return fmt.Errorf("failed to load databases: %w", err)
+		}
+		defer mmClient.Close()
+
+		opts := maxmind.ExportOptions{
+			Format:    format,
+			IPVersion: exportIPVersion,
+			Language:  maxmind.NegotiateLanguage(exportLang, ""),
+		}
+		export := func(w io.Writer) (int, error) {
+			return mmClient.Export(maxmind.DBType(args[0]), w, opts)
+		}
+
+		if exportOut == "" {
+			_, err := export(cmd.OutOrStdout())
+			return err
+		}
+		var count int
+		if err := common.WriteFileAtomic(exportOut, func(f *os.File) error {
+			count, err = export(f)
+			return err
+		}); err != nil {
+			return err
+		}
+		cmd.PrintErrf("Exported %d networks to %s\n", count, exportOut)
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+func init() {
+	formats := make([]string, 0, len(maxmind.ExportFormats))
+	for _, f := range maxmind.ExportFormats {
+		formats = append(formats, string(f))
+	}
+
+	exportCmd.Flags().StringVar(&exportFormat, "format", string(maxmind.ExportFormatCSV),
+		fmt.Sprintf("Output format (supported: %s)", strings.Join(formats, ", ")))
+	exportCmd.Flags().IntVar(&exportIPVersion, "ip-version", 0, "Only export IPv4 (4) or IPv6 (6) networks (default: both)")
+	exportCmd.Flags().StringVar(&exportLang, "lang", maxmind.DefaultLanguage,
+		fmt.Sprintf("Language for place names, falls back to English (supported: %s)", strings.Join(maxmind.SupportedLanguages, ", ")))
+	exportCmd.Flags().StringVarP(&exportOut, "out", "o", "", "File to write, replaced atomically (default: standard output)")
+}
@@ -0,0 +1,32 @@
+package maxmind
+
+import (
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/spf13/cobra"
+)
+
+var sha256File string
+
+var importCmd = &cobra.Command{
+	Use:   "import <archive.tar.gz>",
+	Short: "Import a MaxMind database archive",
+	Long: "Import a MaxMind GeoIP2/GeoLite2 database archive downloaded on another machine, for use in air-gapped networks. " +
+		"The archive is verified against the sha256 file if one is given. A running server sharing the data directory reloads the database automatically.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mmClient := maxmind.NewClient(&config.Current.MaxMind, config.Current.Core.DataDir)
+		dbType, err := mmClient.ImportArchive(args[0], sha256File)
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("Imported %s\n", dbType)
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+func init() {
+	importCmd.Flags().StringVar(&sha256File, "sha256", "", "Path to the sha256 file of the archive")
+}
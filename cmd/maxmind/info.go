@@ -0,0 +1,39 @@
+package maxmind
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/spf13/cobra"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show database metadata",
+	Long:  "Show the metadata and last update status of the databases in the local data directory.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider, err := maxmind.NewProvider(config.Current)
+		if err != nil {
+			return err
+		}
+		if err := provider.Load(); err != nil {
+			return fmt.Errorf("failed to load databases: %w", err)
+		}
+		defer provider.Close()
+
+		info := maxmind.NewProviderInfo(provider)
+		// The lookup cache of a short-lived command is always empty.
+		info.Cache = nil
+
+		b, err := json.MarshalIndent(info, "", "    ")
+		if err != nil {
+			return fmt.Errorf("error parsing database info: %w", err)
+		}
+
+		cmd.Println(string(b))
+		return nil
+	},
+	SilenceUsage: true,
+}
@@ -0,0 +1,34 @@
+package maxmind
+
+import (
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/spf13/cobra"
+)
+
+var rollbackTo uint
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <edition>",
+	Short: "Roll back a database to a retained version",
+	Long: "Make a retained version of an edition the current one. Without --to the newest version older than the current one is used. " +
+		"A running server sharing the data directory reloads the database automatically.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mmClient := maxmind.NewClient(&config.Current.MaxMind, config.Current.Core.DataDir)
+		defer mmClient.Close()
+
+		version, err := mmClient.Rollback(maxmind.DBType(args[0]), rollbackTo)
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("Rolled back %s to version %d (built %s)\n", args[0], version.Version, version.BuildEpoch)
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+func init() {
+	rollbackCmd.Flags().UintVar(&rollbackTo, "to", 0, "Build epoch of the version to roll back to")
+}
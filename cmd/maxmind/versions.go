@@ -0,0 +1,32 @@
+package maxmind
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/spf13/cobra"
+)
+
+var versionsCmd = &cobra.Command{
+	Use:   "versions",
+	Short: "List retained database versions",
+	Long:  "List the retained versions of each configured edition in the local data directory, newest first.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mmClient := maxmind.NewClient(&config.Current.MaxMind, config.Current.Core.DataDir)
+		versions, err := mmClient.Versions()
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(versions, "", "    ")
+		if err != nil {
+			return fmt.Errorf("error parsing versions: %w", err)
+		}
+
+		cmd.Println(string(b))
+		return nil
+	},
+	SilenceUsage: true,
+}
@@ -0,0 +1,64 @@
+package mmdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hibare/Waypoint/cmd/common"
+	"github.com/hibare/Waypoint/internal/db/overlays"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/spf13/cobra"
+)
+
+var (
+	from         string
+	out          string
+	databaseType string
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build a MaxMind DB file from overlay data",
+	Long: "Compile a CSV file of networks and their attributes into a MaxMind DB file. The columns are named after the overlay fields: " +
+		"network, description, country, iso_country_code, city, latitude, longitude, asn, organization, and tags.<key> for tags. " +
+		"Written to the data directory and listed in maxmind.custom_editions, the file is loaded next to the MaxMind editions.",
+	Example: "  waypoint mmdb build --from overlays.csv --out corp.mmdb",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := os.Open(from)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = src.Close() }()
+
+		networks, err := overlays.ReadCSV(src)
+		if err != nil {
+			return err
+		}
+
+		opts := maxmind.BuildOptions{
+			DatabaseType: databaseType,
+			Description:  fmt.Sprintf("Built by Waypoint from %s", filepath.Base(from)),
+		}
+		var size int64
+		if err := common.WriteFileAtomic(out, func(f *os.File) error {
+			size, err = maxmind.BuildMMDB(f, networks, opts)
+			return err
+		}); err != nil {
+			return err
+		}
+
+		cmd.Printf("Built %s with %d networks (%d bytes)\n", out, len(networks), size)
+		return nil
+	},
+	SilenceUsage: true,
+}
+
+func init() {
+	buildCmd.Flags().StringVar(&from, "from", "", "CSV file of the networks and their attributes")
+	buildCmd.Flags().StringVarP(&out, "out", "o", "", "MaxMind DB file to write, replaced atomically")
+	buildCmd.Flags().StringVar(&databaseType, "database-type", maxmind.DefaultCustomDatabaseType, "Database type written to the file metadata")
+	_ = buildCmd.MarkFlagRequired("from")
+	_ = buildCmd.MarkFlagRequired("out")
+}
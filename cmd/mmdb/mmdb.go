@@ -0,0 +1,16 @@
+package mmdb
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var MMDBCmd = &cobra.Command{
+	Use:          "mmdb",
+	Short:        "MaxMind DB file tools",
+	Long:         "Build MaxMind DB files from your own network data, for Waypoint and any other software reading .mmdb files.",
+	SilenceUsage: true,
+}
+
+func init() {
+	MMDBCmd.AddCommand(buildCmd)
+}
@@ -4,8 +4,10 @@ import (
 	"os"
 
 	"github.com/hibare/Waypoint/cmd/db"
+	"github.com/hibare/Waypoint/cmd/export"
 	"github.com/hibare/Waypoint/cmd/lookup"
 	"github.com/hibare/Waypoint/cmd/maxmind"
+	"github.com/hibare/Waypoint/cmd/mmdb"
 	"github.com/hibare/Waypoint/cmd/server"
 	"github.com/hibare/Waypoint/internal/config"
 	"github.com/hibare/Waypoint/internal/constants"
@@ -41,6 +43,8 @@ func init() {
 	// Add subcommands
 	rootCmd.AddCommand(db.DBCmd)
 	rootCmd.AddCommand(maxmind.MaxmindCmd)
+	rootCmd.AddCommand(mmdb.MMDBCmd)
 	rootCmd.AddCommand(lookup.LookupCmd)
+	rootCmd.AddCommand(export.ExportCmd)
 	rootCmd.AddCommand(server.ServeCmd)
 }
@@ -8,4 +8,5 @@ var (
 	ErrSomethingWentWrong     = errors.New("something went wrong")
 	ErrUnauthorized           = errors.New("unauthorized")
 	ErrReadingPayload         = errors.New("unable to read payload")
+	ErrAdminRequired          = errors.New("admin access required")
 )
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+	commonErrors "github.com/hibare/GoCommon/v2/pkg/errors"
+	commonHttp "github.com/hibare/GoCommon/v2/pkg/http"
+	appErrors "github.com/hibare/Waypoint/cmd/server/errors"
+	"github.com/hibare/Waypoint/cmd/server/utils"
+	"github.com/hibare/Waypoint/internal/maxmind"
+)
+
+var (
+	ErrVersionsNotSupported = errors.New("the provider does not retain database versions")
+)
+
+// Databases handles database metadata requests.
+type Databases struct {
+	provider maxmind.Provider
+}
+
+// NewDatabases creates a new databases handler.
+func NewDatabases(provider maxmind.Provider) *Databases {
+	return &Databases{provider: provider}
+}
+
+// RollbackInput represents the input for a database rollback request.
+type RollbackInput struct {
+	Edition string `in:"path=edition"`
+	To      uint   `in:"query=to"`
+}
+
+// ListDatabases lists the loaded databases with their metadata and update status.
+func (h *Databases) ListDatabases(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, maxmind.NewProviderInfo(h.provider))
+}
+
+// ListVersions lists the retained versions of each edition.
+func (h *Databases) ListVersions(w http.ResponseWriter, r *http.Request) {
+	client, ok := maxmind.AsClient(h.provider)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusNotImplemented, ErrVersionsNotSupported)
+		return
+	}
+
+	versions, err := client.Versions()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing database versions", "error", err)
+		commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
+		return
+	}
+	render.JSON(w, r, versions)
+}
+
+// Rollback makes the server serve a retained version of an edition.
+func (h *Databases) Rollback(w http.ResponseWriter, r *http.Request) {
+	payload, ok := utils.InputFromContext[RollbackInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	client, ok := maxmind.AsClient(h.provider)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusNotImplemented, ErrVersionsNotSupported)
+		return
+	}
+
+	version, err := client.Rollback(maxmind.DBType(payload.Edition), payload.To)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error rolling back database", "edition", payload.Edition, "version", payload.To, "error", err)
+		switch {
+		case errors.Is(err, maxmind.ErrEditionNotConfigured), errors.Is(err, maxmind.ErrVersionNotFound):
+			commonHttp.WriteErrorResponse(w, http.StatusNotFound, err)
+		case errors.Is(err, maxmind.ErrNoPreviousVersion):
+			commonHttp.WriteErrorResponse(w, http.StatusConflict, err)
+		default:
+			commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
+		}
+		return
+	}
+	commonHttp.WriteJSONResponse(w, http.StatusOK, version)
+}
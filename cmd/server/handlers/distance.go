@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+
+	commonErrors "github.com/hibare/GoCommon/v2/pkg/errors"
+	commonHttp "github.com/hibare/GoCommon/v2/pkg/http"
+	appErrors "github.com/hibare/Waypoint/cmd/server/errors"
+	"github.com/hibare/Waypoint/cmd/server/utils"
+	"github.com/hibare/Waypoint/internal/maxmind"
+)
+
+const (
+	// DefaultTravelSpeedKmh is the cruise speed of an airliner, the fastest way most people travel.
+	DefaultTravelSpeedKmh = 900
+
+	secondsPerHour = 3600
+)
+
+// distanceFields are the lookup fields describing an end, so the ASN database is not queried.
+var distanceFields = []string{"latitude", "longitude", "accuracy_radius_km", "city", "iso_country_code"}
+
+var (
+	ErrDistanceEndpointMissing = errors.New("both from and to are required")
+	ErrNoCoordinates           = errors.New("no coordinates known for IP address")
+	ErrTravelSpeedInvalid      = errors.New("speed_kmh must be positive")
+)
+
+// Distance handles distance calculation requests.
+type Distance struct {
+	provider maxmind.Provider
+}
+
+// NewDistance creates a new Distance handler.
+func NewDistance(provider maxmind.Provider) *Distance {
+	return &Distance{provider: provider}
+}
+
+// DistanceInput represents the input for a distance request. Each end is an IP address or
+// coordinates in the form lat,lon.
+type DistanceInput struct {
+	From     string  `in:"query=from"`
+	To       string  `in:"query=to"`
+	SpeedKmh float64 `in:"query=speed_kmh"`
+}
+
+// DistanceEndpoint describes one end of a distance calculation.
+type DistanceEndpoint struct {
+	Input string `json:"input"`
+	maxmind.Coordinates
+	AccuracyRadius uint16 `json:"accuracy_radius_km"`
+	IP             string `json:"ip,omitempty"`
+	City           string `json:"city,omitempty"`
+	ISOCountryCode string `json:"iso_country_code,omitempty"`
+}
+
+// DistanceResult is the result of a distance calculation.
+type DistanceResult struct {
+	From          DistanceEndpoint `json:"from"`
+	To            DistanceEndpoint `json:"to"`
+	DistanceKm    float64          `json:"distance_km"`
+	DistanceMiles float64          `json:"distance_miles"`
+	// Bearing is the initial bearing from the first to the second end, in degrees clockwise from north.
+	Bearing float64 `json:"bearing_degrees"`
+	// AccuracyRadius is the sum of the accuracy radii of both ends, the error margin of the distance.
+	AccuracyRadius uint32 `json:"accuracy_radius_km"`
+	// MinDistanceKm is the shortest distance possible within the accuracy radii.
+	MinDistanceKm float64 `json:"min_distance_km"`
+	// MinTravelTime is the time needed to cover the shortest possible distance at the travel speed.
+	MinTravelTime float64 `json:"min_travel_time_seconds"`
+	SpeedKmh      float64 `json:"speed_kmh"`
+}
+
+// GetDistance handles requests to calculate the distance between two IP addresses or coordinates.
+func (h *Distance) GetDistance(w http.ResponseWriter, r *http.Request) {
+	payload, ok := utils.InputFromContext[DistanceInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	if payload.From == "" || payload.To == "" {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, ErrDistanceEndpointMissing)
+		return
+	}
+
+	speed := payload.SpeedKmh
+	if speed == 0 {
+		speed = DefaultTravelSpeedKmh
+	}
+	if speed < 0 || math.IsNaN(speed) || math.IsInf(speed, 0) {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, ErrTravelSpeedInvalid)
+		return
+	}
+
+	var ends [2]DistanceEndpoint
+	for i, input := range []string{payload.From, payload.To} {
+		end, err := h.endpoint(input)
+		if err != nil {
+			switch {
+			case errors.Is(err, maxmind.ErrInvalidCoordinates), errors.Is(err, maxmind.ErrInvalidIP):
+				commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+			case errors.Is(err, ErrNoCoordinates), errors.Is(err, maxmind.ErrNonGlobalIP):
+				commonHttp.WriteErrorResponse(w, http.StatusUnprocessableEntity, err)
+			case errors.Is(err, maxmind.ErrNoDataAvailable):
+				commonHttp.WriteErrorResponse(w, http.StatusServiceUnavailable, maxmind.ErrNoDataAvailable)
+			default:
+				slog.ErrorContext(r.Context(), "Error fetching record for ip", "ip", input, "error", err)
+				commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
+			}
+			return
+		}
+		ends[i] = end
+	}
+
+	distance := ends[0].DistanceKm(ends[1].Coordinates)
+	radius := uint32(ends[0].AccuracyRadius) + uint32(ends[1].AccuracyRadius)
+	minDistance := math.Max(0, distance-float64(radius))
+
+	commonHttp.WriteJSONResponse(w, http.StatusOK, DistanceResult{
+		From:           ends[0],
+		To:             ends[1],
+		DistanceKm:     distance,
+		DistanceMiles:  maxmind.KmToMiles(distance),
+		Bearing:        ends[0].BearingTo(ends[1].Coordinates),
+		AccuracyRadius: radius,
+		MinDistanceKm:  minDistance,
+		MinTravelTime:  minDistance / speed * secondsPerHour,
+		SpeedKmh:       speed,
+	})
+}
+
+// endpoint resolves an end of a distance calculation to its coordinates.
+func (h *Distance) endpoint(input string) (DistanceEndpoint, error) {
+	end := DistanceEndpoint{Input: input}
+	if net.ParseIP(input) == nil {
+		coordinates, err := maxmind.ParseCoordinates(input)
+		if err != nil {
+			return end, err
+		}
+		end.Coordinates = coordinates
+		return end, nil
+	}
+
+	geoIP, err := h.provider.IP2Geo(input, maxmind.WithFields(distanceFields...))
+	if err != nil {
+		return end, err
+	}
+	// Addresses without a location decode to 0,0 with no accuracy radius.
+	if geoIP.Latitude == 0 && geoIP.Longitude == 0 && geoIP.AccuracyRadius == 0 {
+		return end, fmt.Errorf("%w: %s", ErrNoCoordinates, input)
+	}
+
+	end.Coordinates = maxmind.Coordinates{Latitude: geoIP.Latitude, Longitude: geoIP.Longitude}
+	end.AccuracyRadius = geoIP.AccuracyRadius
+	end.IP = geoIP.IP
+	end.City = geoIP.City
+	end.ISOCountryCode = geoIP.ISOCountryCode
+	return end, nil
+}
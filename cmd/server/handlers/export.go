@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	commonErrors "github.com/hibare/GoCommon/v2/pkg/errors"
+	commonHttp "github.com/hibare/GoCommon/v2/pkg/http"
+	appErrors "github.com/hibare/Waypoint/cmd/server/errors"
+	"github.com/hibare/Waypoint/cmd/server/utils"
+	"github.com/hibare/Waypoint/internal/maxmind"
+)
+
+var ErrExportNotSupported = errors.New("the provider does not support exports")
+
+// Export handles requests to generate files from the geolocation databases.
+type Export struct {
+	provider maxmind.Provider
+}
+
+// NewExport creates a new Export handler.
+func NewExport(provider maxmind.Provider) *Export {
+	return &Export{provider: provider}
+}
+
+// ACLInput represents the input for an access control list export.
+type ACLInput struct {
+	Country string `in:"query=country"`
+	ASN     string `in:"query=asn"`
+	Format  string `in:"query=format"`
+	Name    string `in:"query=name"`
+}
+
+// GetACL handles requests to generate an access control list of the networks of countries and ASNs.
+func (h *Export) GetACL(w http.ResponseWriter, r *http.Request) {
+	payload, ok := utils.InputFromContext[ACLInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	format := maxmind.ACLFormatPlainCIDR
+	if payload.Format != "" {
+		var err error
+		if format, err = maxmind.ParseACLFormat(payload.Format); err != nil {
+			commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	name := payload.Name
+	if name == "" {
+		name = maxmind.DefaultACLName
+	}
+	if err := maxmind.ValidateACLName(name); err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var filter maxmind.ACLFilter
+	var err error
+	if filter.Countries, err = maxmind.ParseCountryCodes(payload.Country); err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if filter.ASNs, err = maxmind.ParseASNs(payload.ASN); err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	client, ok := maxmind.AsClient(h.provider)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusNotImplemented, ErrExportNotSupported)
+		return
+	}
+
+	networks, err := client.ACLNetworks(filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, maxmind.ErrACLFilterRequired):
+			commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		case errors.Is(err, maxmind.ErrACLEmpty):
+			commonHttp.WriteErrorResponse(w, http.StatusNotFound, err)
+		case errors.Is(err, maxmind.ErrCountryDBNotLoaded), errors.Is(err, maxmind.ErrASNDBNotLoaded):
+			commonHttp.WriteErrorResponse(w, http.StatusServiceUnavailable, err)
+		default:
+			slog.ErrorContext(r.Context(), "Error generating ACL", "error", err)
+			commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
+		}
+		return
+	}
+
+	// Written to a buffer first, so a failure can still be reported with an error response.
+	var buf bytes.Buffer
+	if err := maxmind.WriteACL(&buf, format, name, networks); err != nil {
+		slog.ErrorContext(r.Context(), "Error writing ACL", "error", err)
+		commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+format.Extension()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
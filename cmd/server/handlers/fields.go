@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/hibare/Waypoint/internal/maxmind"
+)
+
+// FieldsInput carries the fields selected for a lookup response, as a comma-separated list.
+type FieldsInput struct {
+	Fields string `in:"query=fields"`
+}
+
+// fields parses the selected fields. An empty list selects all fields.
+func (f FieldsInput) fields() ([]string, error) {
+	return maxmind.ParseFields(f.Fields)
+}
+
+// selection renders a lookup result with only the selected fields.
+type selection struct {
+	geoIP  *maxmind.GeoIP
+	fields []string
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s selection) MarshalJSON() ([]byte, error) {
+	if len(s.fields) == 0 {
+		return json.Marshal(s.geoIP)
+	}
+	selected, err := maxmind.SelectFields(*s.geoIP, s.fields)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(selected)
+}
+
+// selectIncludes drops the enrichments of fields that are not selected, so they are not looked up.
+func selectIncludes(inc includes, fields []string) includes {
+	if len(fields) > 0 && !slices.Contains(fields, "ptr") {
+		inc.ptr = false
+	}
+	return inc
+}
@@ -1,65 +1,291 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
 
-	"github.com/go-chi/chi/v5"
 	commonErrors "github.com/hibare/GoCommon/v2/pkg/errors"
 	commonHttp "github.com/hibare/GoCommon/v2/pkg/http"
+	appErrors "github.com/hibare/Waypoint/cmd/server/errors"
+	"github.com/hibare/Waypoint/cmd/server/middlewares"
+	"github.com/hibare/Waypoint/cmd/server/utils"
+	"github.com/hibare/Waypoint/internal/clientip"
 	"github.com/hibare/Waypoint/internal/config"
 	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/resolver"
+)
+
+var (
+	ErrBatchEmpty    = errors.New("batch must contain at least one IP")
+	ErrBatchTooLarge = errors.New("batch exceeds the maximum number of IPs")
 )
 
 // GeoIP handles GeoIP-related requests.
 type GeoIP struct {
-	maxmind *maxmind.Client
-	cfg     *config.Config
+	provider maxmind.Provider
+	cfg      *config.Config
+	enricher enricher
 }
 
 // NewGeoIP creates a new GeoIP handler.
-func NewGeoIP(mm *maxmind.Client, cfg *config.Config) *GeoIP {
+func NewGeoIP(provider maxmind.Provider, res *resolver.Resolver, cfg *config.Config) *GeoIP {
 	return &GeoIP{
-		maxmind: mm,
-		cfg:     cfg,
+		provider: provider,
+		cfg:      cfg,
+		enricher: enricher{resolver: res, cfg: cfg},
 	}
 }
 
+// LanguageInput carries the language preferences of a lookup request.
+type LanguageInput struct {
+	Lang           string `in:"query=lang"`
+	AcceptLanguage string `in:"header=Accept-Language"`
+}
+
+// language negotiates the response language and advertises it in the Content-Language header.
+func (l LanguageInput) language(w http.ResponseWriter) string {
+	lang := maxmind.NegotiateLanguage(l.Lang, l.AcceptLanguage)
+	w.Header().Set("Content-Language", lang)
+	return lang
+}
+
+// GeoIPInput represents the input for a lookup request for a specific IP.
+type GeoIPInput struct {
+	IP string `in:"path=ip"`
+	LanguageInput
+	IncludeInput
+	FieldsInput
+}
+
+// MyIPInput represents the input for a lookup request for the requester's IP.
+type MyIPInput struct {
+	LanguageInput
+	IncludeInput
+	FieldsInput
+}
+
 // GetGeoIP handles requests to get GeoIP information for a specific IP.
 func (h *GeoIP) GetGeoIP(w http.ResponseWriter, r *http.Request) {
-	ip := chi.URLParam(r, "ip")
+	payload, ok := utils.InputFromContext[GeoIPInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	inc, err := payload.includes()
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
 
-	ipGeo, err := h.maxmind.IP2Geo(ip)
+	fields, err := payload.fields()
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	inc = selectIncludes(inc, fields)
+
+	ip := payload.IP
+	lang := payload.language(w)
+
+	ipGeo, err := h.provider.IP2Geo(ip, maxmind.WithLanguage(lang), maxmind.WithFields(fields...))
 	if err != nil {
 		slog.ErrorContext(r.Context(), "Error fetching record for ip", "ip", ip, "error", err)
-		if errors.Is(err, maxmind.ErrInvalidIP) {
+		switch {
+		case errors.Is(err, maxmind.ErrInvalidIP):
 			commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
-		} else {
+		case errors.Is(err, maxmind.ErrNonGlobalIP):
+			commonHttp.WriteErrorResponse(w, http.StatusUnprocessableEntity, err)
+		case errors.Is(err, maxmind.ErrNoDataAvailable):
+			commonHttp.WriteErrorResponse(w, http.StatusServiceUnavailable, maxmind.ErrNoDataAvailable)
+		default:
 			commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
 		}
 		return
 	}
-	commonHttp.WriteJSONResponse(w, http.StatusOK, ipGeo)
+	h.enricher.enrich(r.Context(), inc, &ipGeo)
+	commonHttp.WriteJSONResponse(w, http.StatusOK, selection{geoIP: &ipGeo, fields: fields})
 }
 
 // GetMyIP handles requests to get GeoIP information for the requester's IP.
 func (h *GeoIP) GetMyIP(w http.ResponseWriter, r *http.Request) {
-	ipStr := r.RemoteAddr
+	payload, ok := utils.InputFromContext[MyIPInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	inc, err := payload.includes()
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	fields, err := payload.fields()
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	inc = selectIncludes(inc, fields)
+
+	client, ok := middlewares.GetClient(r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, clientip.ErrInvalidRemoteAddr)
+		return
+	}
+	ipStr := client.IP.String()
+	lang := payload.language(w)
 
 	if h.cfg.Core.Environment == config.EnvironmentDevelopment || h.cfg.Core.Environment == config.EnvironmentTesting {
 		ipStr = "8.8.8.8"
 	}
 
-	ipGeo, err := h.maxmind.IP2Geo(ipStr)
+	ipGeo, err := h.provider.IP2Geo(ipStr, maxmind.WithLanguage(lang), maxmind.WithFields(fields...))
 	if err != nil {
 		slog.ErrorContext(r.Context(), "Error fetching record for ip", "ip", ipStr, "error", err)
-		if errors.Is(err, maxmind.ErrInvalidIP) {
+		switch {
+		case errors.Is(err, maxmind.ErrInvalidIP):
 			commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
-		} else {
+		case errors.Is(err, maxmind.ErrNonGlobalIP):
+			commonHttp.WriteErrorResponse(w, http.StatusUnprocessableEntity, err)
+		case errors.Is(err, maxmind.ErrNoDataAvailable):
+			commonHttp.WriteErrorResponse(w, http.StatusServiceUnavailable, maxmind.ErrNoDataAvailable)
+		default:
 			commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
 		}
 		return
 	}
-	commonHttp.WriteJSONResponse(w, http.StatusOK, ipGeo)
+	h.enricher.enrich(r.Context(), inc, &ipGeo)
+	commonHttp.WriteJSONResponse(w, http.StatusOK, myIPResult{
+		selection: selection{geoIP: &ipGeo, fields: fields},
+		client:    client,
+	})
+}
+
+// myIPResult renders the lookup result of the requester's IP with the client it was resolved
+// from, whatever fields are selected.
+type myIPResult struct {
+	selection
+	client clientip.Client
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m myIPResult) MarshalJSON() ([]byte, error) {
+	b, err := m.selection.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	client, err := json.Marshal(m.client)
+	if err != nil {
+		return nil, err
+	}
+
+	b = bytes.TrimSuffix(b, []byte("}"))
+	if len(b) > 1 {
+		b = append(b, ',')
+	}
+	b = append(b, `"client":`...)
+	b = append(b, client...)
+	return append(b, '}'), nil
+}
+
+// BatchLookupInput represents the input for a batch lookup request.
+type BatchLookupInput struct {
+	IPs []string `in:"body=json"`
+	LanguageInput
+	IncludeInput
+	FieldsInput
+}
+
+// BatchLookupResult represents the lookup result for a single IP of a batch request.
+type BatchLookupResult struct {
+	IP     string         `json:"ip"`
+	Result *maxmind.GeoIP `json:"result,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	fields []string
+}
+
+// MarshalJSON implements json.Marshaler, rendering only the selected fields of the result.
+func (b BatchLookupResult) MarshalJSON() ([]byte, error) {
+	result := struct {
+		IP     string     `json:"ip"`
+		Result *selection `json:"result,omitempty"`
+		Error  string     `json:"error,omitempty"`
+	}{IP: b.IP, Error: b.Error}
+	if b.Result != nil {
+		result.Result = &selection{geoIP: b.Result, fields: b.fields}
+	}
+	return json.Marshal(result)
+}
+
+// BatchGeoIP handles requests to get GeoIP information for a list of IPs.
+// A failed lookup is reported on its own item instead of failing the whole batch.
+func (h *GeoIP) BatchGeoIP(w http.ResponseWriter, r *http.Request) {
+	payload, ok := utils.InputFromContext[BatchLookupInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	inc, err := payload.includes()
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	fields, err := payload.fields()
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	inc = selectIncludes(inc, fields)
+
+	if len(payload.IPs) == 0 {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, ErrBatchEmpty)
+		return
+	}
+
+	if len(payload.IPs) > h.cfg.Lookup.BatchLimit {
+		commonHttp.WriteErrorResponse(w, http.StatusRequestEntityTooLarge, ErrBatchTooLarge)
+		return
+	}
+
+	lang := payload.language(w)
+	results := make([]BatchLookupResult, len(payload.IPs))
+	seen := make(map[string]int, len(payload.IPs))
+	for i, ip := range payload.IPs {
+		// Log enrichment batches are full of repeats, look each distinct IP up only once.
+		if j, ok := seen[ip]; ok {
+			results[i] = results[j]
+			continue
+		}
+		seen[ip] = i
+
+		results[i] = BatchLookupResult{IP: ip, fields: fields}
+		ipGeo, err := h.provider.IP2Geo(ip, maxmind.WithLanguage(lang), maxmind.WithFields(fields...))
+		if err != nil {
+			if errors.Is(err, maxmind.ErrInvalidIP) || errors.Is(err, maxmind.ErrNonGlobalIP) ||
+				errors.Is(err, maxmind.ErrNoDataAvailable) {
+				results[i].Error = err.Error()
+			} else {
+				slog.ErrorContext(r.Context(), "Error fetching record for ip", "ip", ip, "error", err)
+				results[i].Error = commonErrors.ErrInternalServerError.Error()
+			}
+			continue
+		}
+		results[i].Result = &ipGeo
+	}
+
+	var enrich []*maxmind.GeoIP
+	for i, result := range results {
+		if result.Result != nil && seen[result.IP] == i {
+			enrich = append(enrich, result.Result)
+		}
+	}
+	h.enricher.enrich(r.Context(), inc, enrich...)
+
+	commonHttp.WriteJSONResponse(w, http.StatusOK, results)
 }
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	commonErrors "github.com/hibare/GoCommon/v2/pkg/errors"
+	commonHttp "github.com/hibare/GoCommon/v2/pkg/http"
+	appErrors "github.com/hibare/Waypoint/cmd/server/errors"
+	"github.com/hibare/Waypoint/cmd/server/utils"
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/resolver"
+)
+
+var (
+	ErrHostResolvesToNonGlobal = errors.New("hostname resolves to an address that is not globally reachable")
+)
+
+// Host handles hostname lookup requests.
+type Host struct {
+	provider maxmind.Provider
+	resolver *resolver.Resolver
+	cfg      *config.Config
+	enricher enricher
+}
+
+// NewHost creates a new Host handler.
+func NewHost(provider maxmind.Provider, res *resolver.Resolver, cfg *config.Config) *Host {
+	return &Host{
+		provider: provider,
+		resolver: res,
+		cfg:      cfg,
+		enricher: enricher{resolver: res, cfg: cfg},
+	}
+}
+
+// HostInput represents the input for a hostname lookup request.
+type HostInput struct {
+	Name string `in:"path=name"`
+	LanguageInput
+	IncludeInput
+	FieldsInput
+}
+
+// HostRecordResult represents the lookup result for a single address of a hostname.
+type HostRecordResult struct {
+	resolver.Record
+	Result *maxmind.GeoIP `json:"result,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	fields []string
+}
+
+// MarshalJSON implements json.Marshaler, rendering only the selected fields of the result.
+func (h HostRecordResult) MarshalJSON() ([]byte, error) {
+	result := struct {
+		resolver.Record
+		Result *selection `json:"result,omitempty"`
+		Error  string     `json:"error,omitempty"`
+	}{Record: h.Record, Error: h.Error}
+	if h.Result != nil {
+		result.Result = &selection{geoIP: h.Result, fields: h.fields}
+	}
+	return json.Marshal(result)
+}
+
+// HostLookupResult represents the lookup result for a hostname.
+type HostLookupResult struct {
+	Name      string             `json:"name"`
+	CNAMEs    []string           `json:"cname_chain"`
+	Records   []HostRecordResult `json:"records"`
+	Truncated bool               `json:"truncated"`
+}
+
+// GetHost handles requests to resolve a hostname and get GeoIP information for each of its addresses.
+func (h *Host) GetHost(w http.ResponseWriter, r *http.Request) {
+	payload, ok := utils.InputFromContext[HostInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	inc, err := payload.includes()
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	fields, err := payload.fields()
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	inc = selectIncludes(inc, fields)
+
+	lang := payload.language(w)
+
+	resolution, err := h.resolver.LookupHost(r.Context(), payload.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, resolver.ErrInvalidHostname):
+			commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		case errors.Is(err, resolver.ErrHostNotFound):
+			commonHttp.WriteErrorResponse(w, http.StatusNotFound, err)
+		case errors.Is(err, resolver.ErrCNAMEChainTooLong):
+			commonHttp.WriteErrorResponse(w, http.StatusUnprocessableEntity, err)
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(err, resolver.ErrResolveFailed):
+			slog.WarnContext(r.Context(), "Error resolving hostname", "name", payload.Name, "error", err)
+			commonHttp.WriteErrorResponse(w, http.StatusBadGateway, resolver.ErrResolveFailed)
+		default:
+			slog.ErrorContext(r.Context(), "Error resolving hostname", "name", payload.Name, "error", err)
+			commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
+		}
+		return
+	}
+
+	// Refuse names pointing into private space, so the resolver cannot be used to map internal DNS.
+	if h.cfg.Resolver.RefusePrivate {
+		for _, record := range resolution.Records {
+			if _, special := maxmind.ClassifyIP(record.IP); special {
+				commonHttp.WriteErrorResponse(w, http.StatusUnprocessableEntity, ErrHostResolvesToNonGlobal)
+				return
+			}
+		}
+	}
+
+	result := HostLookupResult{
+		Name:      resolution.Name,
+		CNAMEs:    resolution.CNAMEs,
+		Records:   make([]HostRecordResult, len(resolution.Records)),
+		Truncated: resolution.Truncated,
+	}
+	for i, record := range resolution.Records {
+		result.Records[i] = HostRecordResult{Record: record, fields: fields}
+		ipGeo, err := h.provider.IP2Geo(record.IP.String(), maxmind.WithLanguage(lang), maxmind.WithFields(fields...))
+		if err != nil {
+			if errors.Is(err, maxmind.ErrNonGlobalIP) || errors.Is(err, maxmind.ErrNoDataAvailable) {
+				result.Records[i].Error = err.Error()
+			} else {
+				slog.ErrorContext(r.Context(), "Error fetching record for ip", "ip", record.IP, "error", err)
+				result.Records[i].Error = commonErrors.ErrInternalServerError.Error()
+			}
+			continue
+		}
+		result.Records[i].Result = &ipGeo
+	}
+
+	var enrich []*maxmind.GeoIP
+	for _, record := range result.Records {
+		if record.Result != nil {
+			enrich = append(enrich, record.Result)
+		}
+	}
+	h.enricher.enrich(r.Context(), inc, enrich...)
+
+	commonHttp.WriteJSONResponse(w, http.StatusOK, result)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/resolver"
+)
+
+const (
+	// IncludePTR enriches lookup results with the PTR name of the address.
+	IncludePTR = "ptr"
+
+	// ptrConcurrency limits the reverse lookups running at once for a batch.
+	ptrConcurrency = 16
+)
+
+var (
+	ErrUnknownInclude = errors.New("unknown include, supported values are: " + IncludePTR)
+)
+
+// IncludeInput carries the optional enrichments requested for a lookup, as a comma-separated list.
+type IncludeInput struct {
+	Include string `in:"query=include"`
+}
+
+// includes holds the parsed enrichments of a request.
+type includes struct {
+	ptr bool
+}
+
+// includes parses the requested enrichments.
+func (i IncludeInput) includes() (includes, error) {
+	var inc includes
+	for _, v := range strings.Split(i.Include, ",") {
+		switch strings.TrimSpace(v) {
+		case "":
+		case IncludePTR:
+			inc.ptr = true
+		default:
+			return inc, fmt.Errorf("%w: %q", ErrUnknownInclude, v)
+		}
+	}
+	return inc, nil
+}
+
+// enricher adds the requested enrichments to lookup results.
+type enricher struct {
+	resolver *resolver.Resolver
+	cfg      *config.Config
+}
+
+// enrich adds the requested enrichments to the lookup results. Failed enrichments are left out.
+func (e enricher) enrich(ctx context.Context, inc includes, results ...*maxmind.GeoIP) {
+	if !inc.ptr {
+		return
+	}
+
+	sem := make(chan struct{}, ptrConcurrency)
+	var wg sync.WaitGroup
+	for _, geoIP := range results {
+		ip := net.ParseIP(geoIP.IP)
+		if ip == nil {
+			continue
+		}
+		// Do not leak the names of internal addresses from the resolver.
+		if _, special := maxmind.ClassifyIP(ip); special && e.cfg.Resolver.RefusePrivate {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			names, err := e.resolver.LookupPTR(ctx, ip)
+			if err != nil {
+				if !errors.Is(err, resolver.ErrPTRNotFound) {
+					slog.DebugContext(ctx, "Error looking up PTR record", "ip", geoIP.IP, "error", err)
+				}
+				return
+			}
+			geoIP.PTR = names[0]
+		}()
+	}
+	wg.Wait()
+}
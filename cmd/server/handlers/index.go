@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+
+	commonErrors "github.com/hibare/GoCommon/v2/pkg/errors"
+	commonHttp "github.com/hibare/GoCommon/v2/pkg/http"
+	appErrors "github.com/hibare/Waypoint/cmd/server/errors"
+	"github.com/hibare/Waypoint/cmd/server/utils"
+	"github.com/hibare/Waypoint/internal/maxmind"
+)
+
+var ErrReverseIndexUnsupported = errors.New("the provider does not support ASN and country queries")
+
+// Index handles queries of the reverse index of networks by ASN and country.
+type Index struct {
+	provider maxmind.Provider
+}
+
+// NewIndex creates a new Index handler.
+func NewIndex(provider maxmind.Provider) *Index {
+	return &Index{provider: provider}
+}
+
+// ASNInput represents the input for an ASN query.
+type ASNInput struct {
+	ASN string `in:"path=asn"`
+}
+
+// CountryNetworksInput represents the input for a country networks query.
+type CountryNetworksInput struct {
+	ISO    string `in:"path=iso"`
+	Cursor string `in:"query=cursor"`
+	Limit  int    `in:"query=limit"`
+}
+
+// CountryNetworksResult is a page of the networks located in a country.
+type CountryNetworksResult struct {
+	maxmind.CountryNetworks
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// GetASN handles requests to list the networks of an autonomous system.
+func (h *Index) GetASN(w http.ResponseWriter, r *http.Request) {
+	payload, ok := utils.InputFromContext[ASNInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	asn, err := maxmind.ParseASN(payload.ASN)
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	client, ok := h.client(w)
+	if !ok {
+		return
+	}
+
+	result, err := client.ASNNetworks(asn)
+	if err != nil {
+		writeIndexError(w, r, err)
+		return
+	}
+	commonHttp.WriteJSONResponse(w, http.StatusOK, result)
+}
+
+// GetCountryNetworks handles requests to list the networks located in a country.
+func (h *Index) GetCountryNetworks(w http.ResponseWriter, r *http.Request) {
+	payload, ok := utils.InputFromContext[CountryNetworksInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	iso, err := maxmind.ParseCountryCode(payload.ISO)
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit := payload.Limit
+	if limit == 0 {
+		limit = DefaultNetworkLimit
+	}
+	if limit < 0 || limit > MaxNetworkLimit {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, ErrNetworkLimitInvalid)
+		return
+	}
+
+	var cursor net.IP
+	if payload.Cursor != "" {
+		if cursor = net.ParseIP(payload.Cursor); cursor == nil {
+			commonHttp.WriteErrorResponse(w, http.StatusBadRequest, maxmind.ErrInvalidCursor)
+			return
+		}
+	}
+
+	client, ok := h.client(w)
+	if !ok {
+		return
+	}
+
+	networks, next, err := client.CountryNetworks(iso, cursor, limit)
+	if err != nil {
+		writeIndexError(w, r, err)
+		return
+	}
+
+	result := CountryNetworksResult{CountryNetworks: networks}
+	if next != nil {
+		result.NextCursor = next.String()
+	}
+	commonHttp.WriteJSONResponse(w, http.StatusOK, result)
+}
+
+// client returns the MaxMind client of the provider, writing an error response if there is none.
+func (h *Index) client(w http.ResponseWriter) (*maxmind.Client, bool) {
+	client, ok := maxmind.AsClient(h.provider)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusNotImplemented, ErrReverseIndexUnsupported)
+	}
+	return client, ok
+}
+
+func writeIndexError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, maxmind.ErrASNNotFound), errors.Is(err, maxmind.ErrCountryNotFound):
+		commonHttp.WriteErrorResponse(w, http.StatusNotFound, err)
+	case errors.Is(err, maxmind.ErrReverseIndexUnavailable):
+		commonHttp.WriteErrorResponse(w, http.StatusServiceUnavailable, err)
+	case errors.Is(err, maxmind.ErrInvalidCursor):
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+	default:
+		slog.ErrorContext(r.Context(), "Error querying reverse index", "error", err)
+		commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	commonErrors "github.com/hibare/GoCommon/v2/pkg/errors"
+	commonHttp "github.com/hibare/GoCommon/v2/pkg/http"
+	appErrors "github.com/hibare/Waypoint/cmd/server/errors"
+	"github.com/hibare/Waypoint/cmd/server/utils"
+	"github.com/hibare/Waypoint/internal/db/overlays"
+	"github.com/hibare/Waypoint/internal/maxmind"
+)
+
+// MaxMMDBSourceSize is the largest CSV file accepted by a build request.
+const MaxMMDBSourceSize = 32 << 20
+
+// MMDB handles requests to build MaxMind DB files.
+type MMDB struct{}
+
+// NewMMDB creates a new MMDB handler.
+func NewMMDB() *MMDB {
+	return &MMDB{}
+}
+
+// MMDBBuildInput represents the input for a build request. The CSV file is sent as the body.
+type MMDBBuildInput struct {
+	DatabaseType string `in:"query=database_type"`
+}
+
+// BuildMMDB handles requests to compile a CSV file of networks and their attributes into a
+// MaxMind DB file.
+func (h *MMDB) BuildMMDB(w http.ResponseWriter, r *http.Request) {
+	payload, ok := utils.InputFromContext[MMDBBuildInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	networks, err := overlays.ReadCSV(http.MaxBytesReader(w, r.Body, MaxMMDBSourceSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			commonHttp.WriteErrorResponse(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	opts := maxmind.BuildOptions{DatabaseType: payload.DatabaseType, Description: "Built by Waypoint"}
+	if _, err := maxmind.BuildMMDB(&buf, networks, opts); err != nil {
+		if errors.Is(err, maxmind.ErrMMDBNetworkInvalid) {
+			commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error building mmdb", "error", err)
+		commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="custom.mmdb"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
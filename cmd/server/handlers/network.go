@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+
+	commonErrors "github.com/hibare/GoCommon/v2/pkg/errors"
+	commonHttp "github.com/hibare/GoCommon/v2/pkg/http"
+	appErrors "github.com/hibare/Waypoint/cmd/server/errors"
+	"github.com/hibare/Waypoint/cmd/server/utils"
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+)
+
+const (
+	// DefaultNetworkLimit is the number of networks returned per page by default.
+	DefaultNetworkLimit = 100
+
+	// MaxNetworkLimit is the largest number of networks returned per page.
+	MaxNetworkLimit = 1000
+)
+
+var (
+	ErrInvalidNetwork       = errors.New("invalid network, expected a CIDR such as 203.0.113.0/24")
+	ErrNetworkTooLarge      = errors.New("network is larger than the configured maximum")
+	ErrNetworkLimitInvalid  = fmt.Errorf("limit must be between 1 and %d", MaxNetworkLimit)
+	ErrNetworksNotSupported = errors.New("the provider does not support network queries")
+)
+
+// Network handles network query requests.
+type Network struct {
+	provider maxmind.Provider
+	cfg      *config.Config
+}
+
+// NewNetwork creates a new Network handler.
+func NewNetwork(provider maxmind.Provider, cfg *config.Config) *Network {
+	return &Network{provider: provider, cfg: cfg}
+}
+
+// NetworkInput represents the input for a network query. The network is either given as two path
+// segments, the address and the prefix length, or as one segment with an escaped slash.
+type NetworkInput struct {
+	IP     string `in:"path=ip"`
+	Bits   string `in:"path=bits"`
+	Cursor string `in:"query=cursor"`
+	Limit  int    `in:"query=limit"`
+	LanguageInput
+	FieldsInput
+}
+
+// NetworkQueryResult is a page of the distinct networks within a network.
+type NetworkQueryResult struct {
+	Network    string      `json:"network"`
+	Results    []selection `json:"results"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// GetNetwork handles requests to list the distinct networks of the databases within a network.
+func (h *Network) GetNetwork(w http.ResponseWriter, r *http.Request) {
+	payload, ok := utils.InputFromContext[NetworkInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	fields, err := payload.fields()
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	prefix, err := payload.network()
+	if err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.checkSize(prefix); err != nil {
+		commonHttp.WriteErrorResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	limit := payload.Limit
+	if limit == 0 {
+		limit = DefaultNetworkLimit
+	}
+	if limit < 0 || limit > MaxNetworkLimit {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, ErrNetworkLimitInvalid)
+		return
+	}
+
+	var cursor net.IP
+	if payload.Cursor != "" {
+		if cursor = net.ParseIP(payload.Cursor); cursor == nil {
+			commonHttp.WriteErrorResponse(w, http.StatusBadRequest, maxmind.ErrInvalidCursor)
+			return
+		}
+	}
+
+	client, ok := maxmind.AsClient(h.provider)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusNotImplemented, ErrNetworksNotSupported)
+		return
+	}
+
+	lang := payload.language(w)
+	results, next, err := client.NetworksWithin(prefix, cursor, limit, maxmind.WithLanguage(lang), maxmind.WithFields(fields...))
+	if err != nil {
+		switch {
+		case errors.Is(err, maxmind.ErrInvalidCursor):
+			commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+		default:
+			slog.ErrorContext(r.Context(), "Error walking networks", "network", prefix, "error", err)
+			commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, commonErrors.ErrInternalServerError)
+		}
+		return
+	}
+
+	// The network identifies each result, so it is returned even if not selected.
+	if len(fields) > 0 && !slices.Contains(fields, "network") {
+		fields = append(fields, "network")
+	}
+
+	result := NetworkQueryResult{
+		Network: prefix.String(),
+		Results: make([]selection, len(results)),
+	}
+	for i := range results {
+		result.Results[i] = selection{geoIP: &results[i], fields: fields}
+	}
+	if next != nil {
+		result.NextCursor = next.String()
+	}
+	commonHttp.WriteJSONResponse(w, http.StatusOK, result)
+}
+
+// network parses the queried network.
+func (n NetworkInput) network() (*net.IPNet, error) {
+	cidr := n.IP
+	if n.Bits != "" {
+		cidr += "/" + n.Bits
+	}
+	cidr, err := url.PathUnescape(cidr)
+	if err != nil {
+		return nil, ErrInvalidNetwork
+	}
+
+	_, prefix, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, ErrInvalidNetwork
+	}
+	return prefix, nil
+}
+
+// checkSize refuses networks with a shorter prefix than configured.
+func (h *Network) checkSize(prefix *net.IPNet) error {
+	ones, bits := prefix.Mask.Size()
+	minPrefix := h.cfg.Lookup.NetworkMinPrefixIPv6
+	if bits == net.IPv4len*8 {
+		minPrefix = h.cfg.Lookup.NetworkMinPrefixIPv4
+	}
+	if ones < minPrefix {
+		return fmt.Errorf("%w: /%d is shorter than /%d", ErrNetworkTooLarge, ones, minPrefix)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+	commonHttp "github.com/hibare/GoCommon/v2/pkg/http"
+	appErrors "github.com/hibare/Waypoint/cmd/server/errors"
+	"github.com/hibare/Waypoint/cmd/server/utils"
+	"github.com/hibare/Waypoint/internal/db/overlays"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"gorm.io/gorm"
+)
+
+type OverlayHandler struct {
+	db       *gorm.DB
+	overlays *maxmind.OverlaySet
+}
+
+type OverlayPayload struct {
+	Network        string            `json:"network"`
+	Description    string            `json:"description,omitempty"`
+	Country        *string           `json:"country,omitempty"`
+	ISOCountryCode *string           `json:"iso_country_code,omitempty"`
+	City           *string           `json:"city,omitempty"`
+	Latitude       *float64          `json:"latitude,omitempty"`
+	Longitude      *float64          `json:"longitude,omitempty"`
+	ASN            *uint             `json:"asn,omitempty"`
+	Organization   *string           `json:"organization,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+func (p *OverlayPayload) toOverlay() *overlays.Overlay {
+	return &overlays.Overlay{
+		Network:        p.Network,
+		Description:    p.Description,
+		Country:        p.Country,
+		ISOCountryCode: p.ISOCountryCode,
+		City:           p.City,
+		Latitude:       p.Latitude,
+		Longitude:      p.Longitude,
+		ASN:            p.ASN,
+		Organization:   p.Organization,
+		Tags:           p.Tags,
+	}
+}
+
+type OverlayCreateInput struct {
+	Payload *OverlayPayload `in:"body=json"`
+}
+
+type OverlayIDInput struct {
+	ID string `in:"path=id"`
+}
+
+type OverlayUpdateInput struct {
+	ID      string          `in:"path=id"`
+	Payload *OverlayPayload `in:"body=json"`
+}
+
+func NewOverlayHandler(db *gorm.DB, set *maxmind.OverlaySet) *OverlayHandler {
+	return &OverlayHandler{db: db, overlays: set}
+}
+
+// ListOverlays lists all overlays.
+func (h *OverlayHandler) ListOverlays(w http.ResponseWriter, r *http.Request) {
+	list, err := overlays.ListOverlays(r.Context(), h.db, r.URL.Query())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list overlays", "error", err)
+		commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, appErrors.ErrSomethingWentWrong)
+		return
+	}
+
+	render.JSON(w, r, list)
+}
+
+// GetOverlay returns a single overlay.
+func (h *OverlayHandler) GetOverlay(w http.ResponseWriter, r *http.Request) {
+	input, ok := utils.InputFromContext[OverlayIDInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	overlay, err := overlays.GetOverlay(r.Context(), h.db, input.ID)
+	if err != nil {
+		h.writeError(w, r, "failed to get overlay", err)
+		return
+	}
+
+	render.JSON(w, r, overlay)
+}
+
+// CreateOverlay creates a new overlay.
+func (h *OverlayHandler) CreateOverlay(w http.ResponseWriter, r *http.Request) {
+	input, ok := utils.InputFromContext[OverlayCreateInput](r)
+	if !ok || input.Payload == nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	overlay, err := overlays.CreateOverlay(r.Context(), h.db, input.Payload.toOverlay())
+	if err != nil {
+		h.writeError(w, r, "failed to create overlay", err)
+		return
+	}
+	h.reload(r)
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, overlay)
+}
+
+// UpdateOverlay replaces an overlay.
+func (h *OverlayHandler) UpdateOverlay(w http.ResponseWriter, r *http.Request) {
+	input, ok := utils.InputFromContext[OverlayUpdateInput](r)
+	if !ok || input.Payload == nil {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	overlay, err := overlays.UpdateOverlay(r.Context(), h.db, input.ID, input.Payload.toOverlay())
+	if err != nil {
+		h.writeError(w, r, "failed to update overlay", err)
+		return
+	}
+	h.reload(r)
+
+	render.JSON(w, r, overlay)
+}
+
+// DeleteOverlay deletes an overlay.
+func (h *OverlayHandler) DeleteOverlay(w http.ResponseWriter, r *http.Request) {
+	input, ok := utils.InputFromContext[OverlayIDInput](r)
+	if !ok {
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, appErrors.ErrReadingPayload)
+		return
+	}
+
+	if err := overlays.DeleteOverlay(r.Context(), h.db, input.ID); err != nil {
+		h.writeError(w, r, "failed to delete overlay", err)
+		return
+	}
+	h.reload(r)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reload makes a change visible to lookups right away instead of on the next refresh.
+func (h *OverlayHandler) reload(r *http.Request) {
+	if err := overlays.LoadOverlays(r.Context(), h.db, h.overlays); err != nil {
+		slog.ErrorContext(r.Context(), "failed to reload overlays", "error", err)
+	}
+}
+
+func (h *OverlayHandler) writeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	switch {
+	case errors.Is(err, overlays.ErrOverlayNotFound):
+		commonHttp.WriteErrorResponse(w, http.StatusNotFound, err)
+	case errors.Is(err, overlays.ErrDuplicateOverlayNetwork):
+		commonHttp.WriteErrorResponse(w, http.StatusConflict, err)
+	case errors.Is(err, overlays.ErrInvalidOverlayNetwork),
+		errors.Is(err, overlays.ErrInvalidOverlayCountryCode),
+		errors.Is(err, overlays.ErrInvalidOverlayCoordinates),
+		errors.Is(err, overlays.ErrInvalidOverlayField),
+		errors.Is(err, overlays.ErrEmptyOverlay):
+		commonHttp.WriteErrorResponse(w, http.StatusBadRequest, err)
+	default:
+		slog.ErrorContext(r.Context(), msg, "error", err)
+		commonHttp.WriteErrorResponse(w, http.StatusInternalServerError, appErrors.ErrSomethingWentWrong)
+	}
+}
@@ -67,6 +67,24 @@ func UnifiedAuthMiddleware(db *gorm.DB) func(http.Handler) http.Handler {
 	}
 }
 
+// AdminMiddleware restricts routes to authenticated users in one of the admin groups.
+func AdminMiddleware(cfg *config.OIDCConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetAuthUser(r)
+			if !ok {
+				commonHttp.WriteErrorResponse(w, http.StatusUnauthorized, errors.ErrAuthenticationRequired)
+				return
+			}
+			if !cfg.IsAdmin(user.UserGroups) {
+				commonHttp.WriteErrorResponse(w, http.StatusForbidden, errors.ErrAdminRequired)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // tryCookieAuth attempts to authenticate via JWT cookie.
 func tryCookieAuth(r *http.Request) *auth.UserJWTClaims {
 	token, err := utils.GetJWTFromCookie(r)
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/hibare/Waypoint/cmd/server/utils"
+	"github.com/hibare/Waypoint/internal/clientip"
+)
+
+// ClientContextKey is the key for the resolved client in request context.
+type ClientContextKey string
+
+const ClientKey ClientContextKey = "client"
+
+// GetClient retrieves the resolved client from request context.
+func GetClient(r *http.Request) (clientip.Client, bool) {
+	return utils.FromRequestContext[clientip.Client](r, ClientKey)
+}
+
+// ClientIPMiddleware resolves the client of each request, believing the forwarding headers of
+// trusted proxies only, and sets RemoteAddr to its address so it is logged.
+func ClientIPMiddleware(resolver *clientip.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client, err := resolver.Resolve(r.RemoteAddr, r.Header)
+			if err != nil {
+				slog.WarnContext(r.Context(), "Error resolving client address", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r.RemoteAddr = client.IP.String()
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ClientKey, client)))
+		})
+	}
+}
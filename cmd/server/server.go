@@ -20,10 +20,13 @@ import (
 	"github.com/go-chi/httplog/v3"
 	"github.com/hibare/Waypoint/cmd/server/handlers"
 	"github.com/hibare/Waypoint/cmd/server/middlewares"
+	"github.com/hibare/Waypoint/internal/clientip"
 	"github.com/hibare/Waypoint/internal/config"
 	"github.com/hibare/Waypoint/internal/constants"
 	"github.com/hibare/Waypoint/internal/db"
+	"github.com/hibare/Waypoint/internal/db/overlays"
 	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/resolver"
 	"github.com/spf13/cobra"
 	"gorm.io/gorm"
 )
@@ -56,31 +59,46 @@ func getLogLevel(level string) slog.Level {
 
 // Server represents the HTTP server.
 type Server struct {
-	cfg     *config.Config
-	router  *chi.Mux
-	ctx     context.Context
-	maxmind *maxmind.Client
-	db      *gorm.DB
+	cfg      *config.Config
+	router   *chi.Mux
+	ctx      context.Context
+	provider maxmind.Provider
+	overlays *maxmind.OverlaySet
+	db       *gorm.DB
 }
 
 // NewServer creates a new Server instance.
-func NewServer(ctx context.Context, cfg *config.Config, mm *maxmind.Client, db *gorm.DB) *Server {
+func NewServer(ctx context.Context, cfg *config.Config, provider maxmind.Provider, overlaySet *maxmind.OverlaySet, db *gorm.DB) *Server {
 	return &Server{
-		ctx:     ctx,
-		cfg:     cfg,
-		maxmind: mm,
-		db:      db,
+		ctx:      ctx,
+		cfg:      cfg,
+		provider: provider,
+		overlays: overlaySet,
+		db:       db,
 	}
 }
 
 // Init initializes the server with handlers, routes and middleware.
 func (s *Server) Init() error {
 	apiKeyHandler := handlers.NewAPIKeyHandler(s.db)
-	geoIPHandler := handlers.NewGeoIP(s.maxmind, s.cfg)
+	dnsResolver := resolver.New(&s.cfg.Resolver)
+	geoIPHandler := handlers.NewGeoIP(s.provider, dnsResolver, s.cfg)
+	databasesHandler := handlers.NewDatabases(s.provider)
+	overlayHandler := handlers.NewOverlayHandler(s.db, s.overlays)
+	hostHandler := handlers.NewHost(s.provider, dnsResolver, s.cfg)
+	distanceHandler := handlers.NewDistance(s.provider)
+	networkHandler := handlers.NewNetwork(s.provider, s.cfg)
+	indexHandler := handlers.NewIndex(s.provider)
+	exportHandler := handlers.NewExport(s.provider)
+	mmdbHandler := handlers.NewMMDB()
 	authHandler, err := handlers.NewAuth(s.ctx, s.cfg, s.db)
 	if err != nil {
 		return fmt.Errorf("failed to create auth handler: %w", err)
 	}
+	trustedProxies, err := s.cfg.Server.TrustedProxyPrefixes()
+	if err != nil {
+		return err
+	}
 
 	s.router = chi.NewRouter()
 
@@ -94,7 +112,7 @@ func (s *Server) Init() error {
 	}
 
 	s.router.Use(middleware.RequestID)
-	s.router.Use(middleware.RealIP)
+	s.router.Use(middlewares.ClientIPMiddleware(clientip.NewResolver(trustedProxies, s.cfg.Server.ClientIPHeader)))
 	s.router.Use(httplog.RequestLogger(httpLogger, httpOptions))
 	s.router.Use(middleware.Recoverer)
 	s.router.Use(middleware.Timeout(middlewareTimeout))
@@ -106,7 +124,7 @@ func (s *Server) Init() error {
 	s.router.Route("/api/v1", func(r chi.Router) {
 		// Public auth endpoints.
 		r.Group(func(r chi.Router) {
-			r.Get("/ip", geoIPHandler.GetMyIP)
+			r.With(httpin.NewInput(handlers.MyIPInput{})).Get("/ip", geoIPHandler.GetMyIP)
 			r.Route("/auth", func(r chi.Router) {
 				r.With(httpin.NewInput(handlers.LoginInput{})).Get("/login", authHandler.Login)
 				r.With(httpin.NewInput(handlers.CallbackInput{})).Get("/callback", authHandler.Callback)
@@ -118,7 +136,31 @@ func (s *Server) Init() error {
 		r.Group(func(r chi.Router) {
 			// Unified auth: supports both API key and cookie authentication
 			r.Use(middlewares.UnifiedAuthMiddleware(s.db))
-			r.Get("/ip/{ip}", geoIPHandler.GetGeoIP)
+			r.With(httpin.NewInput(handlers.GeoIPInput{})).Get("/ip/{ip}", geoIPHandler.GetGeoIP)
+			r.With(httpin.NewInput(handlers.BatchLookupInput{})).Post("/ip/batch", geoIPHandler.BatchGeoIP)
+			r.With(httpin.NewInput(handlers.HostInput{})).Get("/host/{name}", hostHandler.GetHost)
+			r.With(httpin.NewInput(handlers.DistanceInput{})).Get("/distance", distanceHandler.GetDistance)
+			r.With(httpin.NewInput(handlers.NetworkInput{})).Get("/network/{ip}", networkHandler.GetNetwork)
+			r.With(httpin.NewInput(handlers.NetworkInput{})).Get("/network/{ip}/{bits}", networkHandler.GetNetwork)
+			r.With(httpin.NewInput(handlers.ASNInput{})).Get("/asn/{asn}", indexHandler.GetASN)
+			r.With(httpin.NewInput(handlers.CountryNetworksInput{})).Get("/country/{iso}/networks", indexHandler.GetCountryNetworks)
+			r.With(httpin.NewInput(handlers.ACLInput{})).Get("/export/acl", exportHandler.GetACL)
+			r.With(middlewares.AdminMiddleware(&s.cfg.OIDC), httpin.NewInput(handlers.MMDBBuildInput{})).
+				Post("/mmdb/build", mmdbHandler.BuildMMDB)
+			r.Route("/databases", func(r chi.Router) {
+				r.Get("/", databasesHandler.ListDatabases)
+				r.Get("/versions", databasesHandler.ListVersions)
+				r.With(middlewares.AdminMiddleware(&s.cfg.OIDC), httpin.NewInput(handlers.RollbackInput{})).
+					Post("/{edition}/rollback", databasesHandler.Rollback)
+			})
+			r.Route("/overlays", func(r chi.Router) {
+				r.Use(middlewares.AdminMiddleware(&s.cfg.OIDC))
+				r.Get("/", overlayHandler.ListOverlays)
+				r.With(httpin.NewInput(handlers.OverlayCreateInput{})).Post("/", overlayHandler.CreateOverlay)
+				r.With(httpin.NewInput(handlers.OverlayIDInput{})).Get("/{id}", overlayHandler.GetOverlay)
+				r.With(httpin.NewInput(handlers.OverlayUpdateInput{})).Put("/{id}", overlayHandler.UpdateOverlay)
+				r.With(httpin.NewInput(handlers.OverlayIDInput{})).Delete("/{id}", overlayHandler.DeleteOverlay)
+			})
 			r.Get("/auth/me", authHandler.Me)
 
 			// api keys routes
@@ -228,41 +270,75 @@ var ServeCmd = &cobra.Command{
 	Aliases:      []string{"server", "run"},
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := cmd.Context()
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
 
 		dbConn, err := db.New(ctx, config.Current)
 		if err != nil {
 			return err
 		}
 
-		// Initialize MaxMind client
-		mmClient := maxmind.NewClient(&config.Current.MaxMind, config.Current.Core.DataDir)
+		// Initialize geolocation provider
+		provider, err := maxmind.NewProvider(config.Current)
+		if err != nil {
+			return err
+		}
+		// Only the server queries the reverse index, so commands skip the cost of building it.
+		if client, ok := maxmind.AsClient(provider); ok && config.Current.MaxMind.ReverseIndex {
+			client.EnableReverseIndex()
+		}
 
 		// Download DB if in production or missing
 		if config.Current.Core.Environment != config.EnvironmentDevelopment {
-			if err := mmClient.DownloadAllDB(); err != nil {
+			if err := provider.Update(ctx); err != nil {
 				return err
 			}
 		} else {
 			// Try to load existing DBs
-			if err := mmClient.Load(); err != nil {
-				slog.Warn("Failed to load MaxMind databases", "error", err)
+			if err := provider.Load(); err != nil {
+				slog.Warn("Failed to load provider databases", "provider", provider.Name(), "error", err)
 			}
 		}
 
 		// Schedule background updates
-		if config.Current.MaxMind.AutoUpdate {
-			go mmClient.RunDBDownloadJob(ctx)
+		switch config.Current.Provider.Type {
+		case config.ProviderTypeMaxMind:
+			if config.Current.MaxMind.AutoUpdate {
+				go maxmind.RunUpdateJob(ctx, provider, config.Current.MaxMind.AutoUpdateInterval)
+			}
+			if client, ok := maxmind.AsClient(provider); ok && config.Current.MaxMind.ImportWatchInterval > 0 {
+				go maxmind.RunImportWatchJob(ctx, client, config.Current.MaxMind.ImportWatchInterval)
+			}
+		case config.ProviderTypeMMDB:
+			if config.Current.Provider.MMDB.ReloadInterval > 0 {
+				go maxmind.RunUpdateJob(ctx, provider, config.Current.Provider.MMDB.ReloadInterval)
+			}
+		}
+
+		// Merge network overlays on top of the provider results
+		overlaySet := maxmind.NewOverlaySet()
+		if err := overlays.LoadOverlays(ctx, dbConn.DB, overlaySet); err != nil {
+			return fmt.Errorf("failed to load overlays: %w", err)
+		}
+		if config.Current.Overlays.RefreshInterval > 0 {
+			go overlays.RunRefreshJob(ctx, dbConn.DB, overlaySet, config.Current.Overlays.RefreshInterval)
 		}
+		provider = maxmind.NewOverlayProvider(provider, overlaySet)
 
 		// Create and initialize server
-		server := NewServer(ctx, config.Current, mmClient, dbConn.DB)
+		server := NewServer(ctx, config.Current, provider, overlaySet, dbConn.DB)
 		if err := server.Init(); err != nil {
 			return err
 		}
 
 		// Start serving
-		return server.serve()
+		err = server.serve()
+
+		// Background jobs, such as the comparisons of updated databases, are stopped before the
+		// databases are closed.
+		cancel()
+		provider.Close()
+		return err
 	},
 }
 
@@ -0,0 +1,171 @@
+// Package clientip resolves the address of the client of a request sent through reverse proxies,
+// believing only the forwarding header the trusted proxies set.
+package clientip
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// SourceRemoteAddr is the source of a client address taken from the connection.
+const SourceRemoteAddr = "remote_addr"
+
+// unknownHop stands for a Forwarded element without a for parameter.
+const unknownHop = "unknown"
+
+// ErrInvalidRemoteAddr is returned when the address of the connection is not an IP address.
+var ErrInvalidRemoteAddr = errors.New("invalid remote address")
+
+// Hop is an address a request was forwarded from.
+type Hop struct {
+	// Address is the IP address, or the value given by the proxy if it is not one, such as an
+	// obfuscated identifier.
+	Address string `json:"address"`
+	// Trusted reports whether the address is a trusted proxy that the chain was walked through.
+	Trusted bool `json:"trusted"`
+}
+
+// Client is the resolved client of a request.
+type Client struct {
+	IP netip.Addr `json:"ip"`
+	// Source is the header the address was read from, or remote_addr for the connection.
+	Source string `json:"source"`
+	// Hops are the addresses the request passed through, from the client to the connected peer.
+	Hops []Hop `json:"hops"`
+}
+
+// Resolver resolves client addresses.
+type Resolver struct {
+	trusted []netip.Prefix
+	header  string
+}
+
+// NewResolver creates a resolver believing the given forwarding header of the proxies in the
+// trusted networks. Forwarded and X-Forwarded-For list the chain of addresses, any other header,
+// such as X-Real-IP or CF-Connecting-IP, the client alone.
+func NewResolver(trusted []netip.Prefix, header string) *Resolver {
+	return &Resolver{trusted: trusted, header: header}
+}
+
+// Trusted reports whether an address is a trusted proxy.
+func (r *Resolver) Trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return slices.ContainsFunc(r.trusted, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// Resolve returns the client of a request. The forwarding header of the resolver is only read when
+// the connected peer is a trusted proxy, and other forwarding headers are ignored, as clients can
+// send them. The forwarded chain is walked from the right, and the first address that is not a
+// trusted proxy is the client. If every address is trusted, the leftmost one is; a hop that is
+// not an IP address stops the walk at the hop after it.
+func (r *Resolver) Resolve(remoteAddr string, header http.Header) (Client, error) {
+	peer, ok := parseAddr(remoteAddr)
+	if !ok {
+		return Client{}, fmt.Errorf("%w: %s", ErrInvalidRemoteAddr, remoteAddr)
+	}
+
+	client := Client{IP: peer, Source: SourceRemoteAddr}
+	peerHop := Hop{Address: peer.String(), Trusted: r.Trusted(peer)}
+	if !peerHop.Trusted {
+		client.Hops = []Hop{peerHop}
+		return client, nil
+	}
+
+	forwarded := forwardedChain(header, r.header)
+	client.Hops = make([]Hop, 0, len(forwarded)+1)
+	for _, value := range forwarded {
+		client.Hops = append(client.Hops, Hop{Address: value})
+	}
+	client.Hops = append(client.Hops, peerHop)
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := &client.Hops[i]
+		addr, ok := parseAddr(hop.Address)
+		if !ok {
+			break
+		}
+		hop.Address = addr.String()
+		client.IP, client.Source = addr, r.header
+		if !r.Trusted(addr) {
+			break
+		}
+		hop.Trusted = true
+	}
+	return client, nil
+}
+
+// forwardedChain returns the addresses a forwarding header lists, from the client to the last
+// proxy.
+func forwardedChain(header http.Header, name string) []string {
+	switch http.CanonicalHeaderKey(name) {
+	case "Forwarded":
+		return parseForwarded(header.Values(name))
+	case "X-Forwarded-For":
+		var hops []string
+		for _, value := range header.Values(name) {
+			for hop := range strings.SplitSeq(value, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+		return hops
+	default:
+		if value := strings.TrimSpace(header.Get(name)); value != "" {
+			return []string{value}
+		}
+		return nil
+	}
+}
+
+// parseForwarded returns the for parameters of the elements of RFC 7239 Forwarded headers, with
+// unknown for elements without one.
+func parseForwarded(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, element := range splitUnquoted(value, ',') {
+			hop := unknownHop
+			for _, pair := range splitUnquoted(element, ';') {
+				key, v, ok := strings.Cut(pair, "=")
+				if ok && strings.EqualFold(strings.TrimSpace(key), "for") {
+					hop = strings.Trim(strings.TrimSpace(v), `"`)
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// splitUnquoted splits s around the separators outside of quoted strings.
+func splitUnquoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseAddr parses an IP address, optionally in brackets or with a port, as proxies and
+// RemoteAddr write them.
+func parseAddr(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")); err == nil {
+		return addr.Unmap().WithZone(""), true
+	}
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap().WithZone(""), true
+	}
+	return netip.Addr{}, false
+}
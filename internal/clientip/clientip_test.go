@@ -0,0 +1,157 @@
+package clientip_test
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/clientip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+
+	testCases := []struct {
+		name           string
+		clientIPHeader string
+		remoteAddr     string
+		header         http.Header
+		ip             string
+		source         string
+		hops           []clientip.Hop
+	}{
+		{
+			name:           "untrusted peer",
+			clientIPHeader: "X-Forwarded-For",
+			remoteAddr:     "203.0.113.7:51234",
+			header:         http.Header{"X-Forwarded-For": {"198.51.100.1"}, "Cf-Connecting-Ip": {"198.51.100.2"}},
+			ip:             "203.0.113.7",
+			source:         clientip.SourceRemoteAddr,
+			hops:           []clientip.Hop{{Address: "203.0.113.7"}},
+		},
+		{
+			name:           "trusted peer without headers",
+			clientIPHeader: "X-Forwarded-For",
+			remoteAddr:     "10.0.0.2:443",
+			header:         http.Header{},
+			ip:             "10.0.0.2",
+			source:         clientip.SourceRemoteAddr,
+			hops:           []clientip.Hop{{Address: "10.0.0.2", Trusted: true}},
+		},
+		{
+			name:           "x-forwarded-for stops at the first untrusted hop",
+			clientIPHeader: "X-Forwarded-For",
+			remoteAddr:     "10.0.0.2:443",
+			header:         http.Header{"X-Forwarded-For": {"192.0.2.66, 203.0.113.7", "10.1.1.1"}},
+			ip:             "203.0.113.7",
+			source:         "X-Forwarded-For",
+			hops: []clientip.Hop{
+				{Address: "192.0.2.66"},
+				{Address: "203.0.113.7"},
+				{Address: "10.1.1.1", Trusted: true},
+				{Address: "10.0.0.2", Trusted: true},
+			},
+		},
+		{
+			name:           "forwarded",
+			clientIPHeader: "Forwarded",
+			remoteAddr:     "[2001:db8::1]:443",
+			header: http.Header{"Forwarded": {
+				`for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"`,
+			}},
+			ip:     "192.0.2.60",
+			source: "Forwarded",
+			hops: []clientip.Hop{
+				{Address: "192.0.2.60"},
+				{Address: "2001:db8:cafe::17", Trusted: true},
+				{Address: "2001:db8::1", Trusted: true},
+			},
+		},
+		{
+			name:           "obfuscated hop",
+			clientIPHeader: "Forwarded",
+			remoteAddr:     "10.0.0.2:443",
+			header:         http.Header{"Forwarded": {`for=_hidden, for=10.0.0.3`}},
+			ip:             "10.0.0.3",
+			source:         "Forwarded",
+			hops: []clientip.Hop{
+				{Address: "_hidden"},
+				{Address: "10.0.0.3", Trusted: true},
+				{Address: "10.0.0.2", Trusted: true},
+			},
+		},
+		{
+			name:           "cdn header",
+			clientIPHeader: "True-Client-IP",
+			remoteAddr:     "10.0.0.2:443",
+			header: http.Header{
+				"True-Client-Ip":  {"198.51.100.9"},
+				"X-Forwarded-For": {"192.0.2.66"},
+			},
+			ip:     "198.51.100.9",
+			source: "True-Client-IP",
+			hops: []clientip.Hop{
+				{Address: "198.51.100.9"},
+				{Address: "10.0.0.2", Trusted: true},
+			},
+		},
+		{
+			name:           "headers injected by the client are ignored",
+			clientIPHeader: "X-Forwarded-For",
+			remoteAddr:     "10.0.0.2:443",
+			header: http.Header{
+				"Cf-Connecting-Ip": {"192.0.2.1"},
+				"True-Client-Ip":   {"192.0.2.2"},
+				"Forwarded":        {"for=192.0.2.3"},
+				"X-Real-Ip":        {"192.0.2.4"},
+				"X-Forwarded-For":  {"192.0.2.5, 203.0.113.7"},
+			},
+			ip:     "203.0.113.7",
+			source: "X-Forwarded-For",
+			hops: []clientip.Hop{
+				{Address: "192.0.2.5"},
+				{Address: "203.0.113.7"},
+				{Address: "10.0.0.2", Trusted: true},
+			},
+		},
+		{
+			name:           "configured header missing",
+			clientIPHeader: "CF-Connecting-IP",
+			remoteAddr:     "10.0.0.2:443",
+			header:         http.Header{"X-Forwarded-For": {"203.0.113.7"}},
+			ip:             "10.0.0.2",
+			source:         clientip.SourceRemoteAddr,
+			hops:           []clientip.Hop{{Address: "10.0.0.2", Trusted: true}},
+		},
+		{
+			name:           "x-real-ip",
+			clientIPHeader: "X-Real-IP",
+			remoteAddr:     "10.0.0.2",
+			header:         http.Header{"X-Real-Ip": {"::ffff:198.51.100.9"}},
+			ip:             "198.51.100.9",
+			source:         "X-Real-IP",
+			hops: []clientip.Hop{
+				{Address: "198.51.100.9"},
+				{Address: "10.0.0.2", Trusted: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := clientip.NewResolver(trusted, tc.clientIPHeader).Resolve(tc.remoteAddr, tc.header)
+			require.NoError(t, err)
+			assert.Equal(t, tc.ip, client.IP.String())
+			assert.Equal(t, tc.source, client.Source)
+			assert.Equal(t, tc.hops, client.Hops)
+		})
+	}
+
+	_, err := clientip.NewResolver(trusted, "X-Forwarded-For").Resolve("@", http.Header{})
+	require.ErrorIs(t, err, clientip.ErrInvalidRemoteAddr)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/hibare/Waypoint/internal/constants"
 )
@@ -35,6 +36,14 @@ type OIDCConfig struct {
 	ClientID     string   `mapstructure:"client_id"`
 	ClientSecret string   `mapstructure:"client_secret"`
 	Scopes       []string `mapstructure:"scopes"`
+	// AdminGroups are the user groups allowed to call the admin endpoints, such as database
+	// rollback. API keys act with the groups of their user. Empty allows no one.
+	AdminGroups []string `mapstructure:"admin_groups"`
+}
+
+// IsAdmin reports whether any of the groups of a user is an admin group.
+func (o *OIDCConfig) IsAdmin(groups []string) bool {
+	return slices.ContainsFunc(groups, func(g string) bool { return slices.Contains(o.AdminGroups, g) })
 }
 
 // Validate checks if the OIDC configuration is valid.
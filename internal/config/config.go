@@ -67,12 +67,16 @@ func (c *CoreConfig) Validate() error {
 
 // Config holds the entire application configuration.
 type Config struct {
-	Core    CoreConfig    `mapstructure:"core"`
-	Server  ServerConfig  `mapstructure:"server"`
-	DB      DBConfig      `mapstructure:"db"`
-	MaxMind MaxMindConfig `mapstructure:"maxmind"`
-	Logger  LoggerConfig  `mapstructure:"logger"`
-	OIDC    OIDCConfig    `mapstructure:"oidc"`
+	Core     CoreConfig     `mapstructure:"core"`
+	Server   ServerConfig   `mapstructure:"server"`
+	DB       DBConfig       `mapstructure:"db"`
+	Provider ProviderConfig `mapstructure:"provider"`
+	MaxMind  MaxMindConfig  `mapstructure:"maxmind"`
+	Lookup   LookupConfig   `mapstructure:"lookup"`
+	Overlays OverlaysConfig `mapstructure:"overlays"`
+	Resolver ResolverConfig `mapstructure:"resolver"`
+	Logger   LoggerConfig   `mapstructure:"logger"`
+	OIDC     OIDCConfig     `mapstructure:"oidc"`
 }
 
 // Validate validates the entire configuration.
@@ -87,11 +91,19 @@ func (c *Config) Validate() error {
 	var vFuncs = []func() error{
 		c.DB.Validate,
 		c.Core.Validate,
-		c.MaxMind.Validate,
+		c.Provider.Validate,
+		c.Lookup.Validate,
+		c.Overlays.Validate,
+		c.Resolver.Validate,
 		c.Server.Validate,
 		c.Logger.Validate,
 	}
 
+	// MaxMind settings are only required when MaxMind is the provider
+	if c.Provider.Type == ProviderTypeMaxMind {
+		vFuncs = append(vFuncs, c.MaxMind.Validate)
+	}
+
 	for _, vf := range vFuncs {
 		if err := vf(); err != nil {
 			return err
@@ -147,14 +159,40 @@ func (c *Config) getViper(ctx context.Context, configPath string) *viper.Viper {
 		"server.request_timeout",
 		"server.cert_file",
 		"server.key_file",
+		"server.trusted_proxies",
+		"server.client_ip_header",
 		"logger.level",
 		"logger.mode",
+		"provider.type",
+		"provider.mmdb.reload_interval",
 		"maxmind.license_key",
 		"maxmind.auto_update",
 		"maxmind.auto_update_interval",
+		"maxmind.editions",
+		"maxmind.import_watch_interval",
+		"maxmind.retain_versions",
+		"maxmind.canaries",
+		"maxmind.cache_size",
+		"maxmind.cache_ttl",
+		"maxmind.reverse_index",
+		"maxmind.custom_editions",
+		"maxmind.diff_on_update",
+		"maxmind.diff_detail",
+		"lookup.batch_limit",
+		"lookup.non_global",
+		"lookup.network_min_prefix_ipv4",
+		"lookup.network_min_prefix_ipv6",
+		"overlays.refresh_interval",
+		"resolver.server",
+		"resolver.timeout",
+		"resolver.max_records",
+		"resolver.refuse_private",
+		"resolver.ptr_timeout",
+		"resolver.ptr_cache_size",
 		"oidc.issuer_url",
 		"oidc.client_id",
 		"oidc.client_secret",
+		"oidc.admin_groups",
 	}
 
 	for _, key := range envKeys {
@@ -179,11 +217,32 @@ func (c *Config) getViper(ctx context.Context, configPath string) *viper.Viper {
 	v.SetDefault("server.idle_timeout", DefaultServerIdleTimeout)
 	v.SetDefault("server.wait_timeout", DefaultServerWaitTimeout)
 	v.SetDefault("server.request_timeout", DefaultServerRequestTimeout)
+	v.SetDefault("server.client_ip_header", DefaultServerClientIPHeader)
 	v.SetDefault("logger.level", commonLogger.LogLevelInfo)
 	v.SetDefault("logger.mode", commonLogger.LogModePretty)
+	v.SetDefault("provider.type", DefaultProviderType)
 	v.SetDefault("maxmind.license_key", "")
 	v.SetDefault("maxmind.auto_update", DefaultMaxMindAutoUpdate)
 	v.SetDefault("maxmind.auto_update_interval", DefaultMaxMindAutoUpdateInterval)
+	v.SetDefault("maxmind.editions", DefaultMaxMindEditions)
+	v.SetDefault("maxmind.import_watch_interval", DefaultMaxMindImportWatchInterval)
+	v.SetDefault("maxmind.retain_versions", DefaultMaxMindRetainVersions)
+	v.SetDefault("maxmind.cache_size", DefaultMaxMindCacheSize)
+	v.SetDefault("maxmind.cache_ttl", DefaultMaxMindCacheTTL)
+	v.SetDefault("maxmind.reverse_index", DefaultMaxMindReverseIndex)
+	v.SetDefault("maxmind.diff_on_update", DefaultMaxMindDiffOnUpdate)
+	v.SetDefault("maxmind.diff_detail", DefaultMaxMindDiffDetail)
+	v.SetDefault("lookup.batch_limit", DefaultLookupBatchLimit)
+	v.SetDefault("lookup.non_global", DefaultLookupNonGlobal)
+	v.SetDefault("lookup.network_min_prefix_ipv4", DefaultLookupNetworkMinPrefixIPv4)
+	v.SetDefault("lookup.network_min_prefix_ipv6", DefaultLookupNetworkMinPrefixIPv6)
+	v.SetDefault("overlays.refresh_interval", DefaultOverlaysRefreshInterval)
+	v.SetDefault("resolver.server", "")
+	v.SetDefault("resolver.timeout", DefaultResolverTimeout)
+	v.SetDefault("resolver.max_records", DefaultResolverMaxRecords)
+	v.SetDefault("resolver.refuse_private", DefaultResolverRefusePrivate)
+	v.SetDefault("resolver.ptr_timeout", DefaultResolverPTRTimeout)
+	v.SetDefault("resolver.ptr_cache_size", DefaultResolverPTRCacheSize)
 	v.SetDefault("oidc.issuer_url", "")
 	v.SetDefault("oidc.client_id", "")
 	v.SetDefault("oidc.client_secret", "")
@@ -2,6 +2,8 @@ package config
 
 import (
 	"context"
+	"net"
+	"net/netip"
 	"os"
 	"strconv"
 	"strings"
@@ -17,6 +19,7 @@ const (
 	testMaxMindLicenseKey         = "test-license"
 	testMaxMindAutoUpdate         = false
 	testMaxMindAutoUpdateInterval = 2 * time.Hour
+	testMaxMindEditions           = "GeoIP2-City,GeoIP2-ISP"
 	testAPIListenAddr             = "127.0.0.1"
 	testAPIListenPort             = 10000
 	testAPIKeys                   = "test-api-key"
@@ -32,6 +35,7 @@ func TestEnvLoadedConfig(t *testing.T) {
 	t.Setenv("WAYPOINT_MAXMIND_LICENSE_KEY", testMaxMindLicenseKey)
 	t.Setenv("WAYPOINT_MAXMIND_AUTO_UPDATE", strconv.FormatBool(testMaxMindAutoUpdate))
 	t.Setenv("WAYPOINT_MAXMIND_AUTO_UPDATE_INTERVAL", testMaxMindAutoUpdateInterval.String())
+	t.Setenv("WAYPOINT_MAXMIND_EDITIONS", testMaxMindEditions)
 	t.Setenv("WAYPOINT_SERVER_LISTEN_ADDR", testAPIListenAddr)
 	t.Setenv("WAYPOINT_SERVER_LISTEN_PORT", strconv.Itoa(testAPIListenPort))
 	t.Setenv("WAYPOINT_CORE_SECRET_KEY", testSecretKey)
@@ -49,6 +53,7 @@ func TestEnvLoadedConfig(t *testing.T) {
 	assert.Equal(t, testMaxMindLicenseKey, Current.MaxMind.LicenseKey)
 	assert.Equal(t, testMaxMindAutoUpdate, Current.MaxMind.AutoUpdate)
 	assert.Equal(t, testMaxMindAutoUpdateInterval, Current.MaxMind.AutoUpdateInterval)
+	assert.Equal(t, strings.Split(testMaxMindEditions, ","), Current.MaxMind.Editions)
 	assert.Equal(t, testSecretKey, Current.Core.SecretKey)
 	assert.True(t, strings.HasSuffix(Current.Core.DataDir, "data"))
 
@@ -81,6 +86,25 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, DefaultServerListenPort, Current.Server.ListenPort)
 	assert.True(t, Current.MaxMind.AutoUpdate)
 	assert.Equal(t, DefaultMaxMindAutoUpdateInterval, Current.MaxMind.AutoUpdateInterval)
+	assert.Equal(t, DefaultMaxMindEditions, Current.MaxMind.Editions)
+	assert.Equal(t, DefaultMaxMindImportWatchInterval, Current.MaxMind.ImportWatchInterval)
+	assert.Equal(t, DefaultMaxMindRetainVersions, Current.MaxMind.RetainVersions)
+	assert.Equal(t, DefaultMaxMindCacheSize, Current.MaxMind.CacheSize)
+	assert.Equal(t, DefaultMaxMindCacheTTL, Current.MaxMind.CacheTTL)
+	assert.True(t, Current.MaxMind.ReverseIndex)
+	assert.True(t, Current.MaxMind.DiffOnUpdate)
+	assert.False(t, Current.MaxMind.DiffDetail)
+	assert.Equal(t, DefaultLookupBatchLimit, Current.Lookup.BatchLimit)
+	assert.Equal(t, DefaultLookupNonGlobal, Current.Lookup.NonGlobal)
+	assert.Equal(t, DefaultLookupNetworkMinPrefixIPv4, Current.Lookup.NetworkMinPrefixIPv4)
+	assert.Equal(t, DefaultLookupNetworkMinPrefixIPv6, Current.Lookup.NetworkMinPrefixIPv6)
+	assert.Equal(t, DefaultResolverTimeout, Current.Resolver.Timeout)
+	assert.Equal(t, DefaultResolverMaxRecords, Current.Resolver.MaxRecords)
+	assert.True(t, Current.Resolver.RefusePrivate)
+	assert.Equal(t, DefaultResolverPTRTimeout, Current.Resolver.PTRTimeout)
+	assert.Equal(t, DefaultResolverPTRCacheSize, Current.Resolver.PTRCacheSize)
+	assert.Equal(t, DefaultProviderType, Current.Provider.Type)
+	assert.Equal(t, DefaultOverlaysRefreshInterval, Current.Overlays.RefreshInterval)
 	assert.NotEmpty(t, Current.Core.SecretKey)
 }
 
@@ -112,8 +136,9 @@ func TestServerConfigValidation(t *testing.T) {
 		{
 			name: "valid config",
 			config: ServerConfig{
-				ListenAddr: "0.0.0.0",
-				ListenPort: 5000,
+				ListenAddr:     "0.0.0.0",
+				ListenPort:     5000,
+				ClientIPHeader: DefaultServerClientIPHeader,
 			},
 			expectErr: nil,
 		},
@@ -133,6 +158,16 @@ func TestServerConfigValidation(t *testing.T) {
 			},
 			expectErr: ErrAPIListenPortInvalid,
 		},
+		{
+			name: "valid trusted proxies",
+			config: ServerConfig{
+				ListenAddr:     "0.0.0.0",
+				ListenPort:     5000,
+				TrustedProxies: []string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.1"},
+				ClientIPHeader: DefaultServerClientIPHeader,
+			},
+			expectErr: nil,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -143,6 +178,30 @@ func TestServerConfigValidation(t *testing.T) {
 	}
 }
 
+func TestServerConfigTrustedProxies(t *testing.T) {
+	config := ServerConfig{ListenPort: 5000, TrustedProxies: []string{"10.0.0.0/8", " 192.0.2.1", "::ffff:198.51.100.7"}}
+	prefixes, err := config.TrustedProxyPrefixes()
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.1/32"),
+		netip.MustParsePrefix("198.51.100.7/32"),
+	}, prefixes)
+
+	config.TrustedProxies = []string{"proxy.internal"}
+	require.ErrorIs(t, config.Validate(), ErrTrustedProxyInvalid)
+}
+
+func TestServerConfigClientIPHeader(t *testing.T) {
+	config := ServerConfig{ListenPort: 5000, ClientIPHeader: " cf-connecting-ip"}
+	config.PostProcess()
+	assert.Equal(t, "CF-Connecting-IP", config.ClientIPHeader)
+	require.NoError(t, config.Validate())
+
+	config.ClientIPHeader = "X-Client-IP"
+	require.ErrorIs(t, config.Validate(), ErrClientIPHeaderInvalid)
+}
+
 func TestCoreConfigValidation(t *testing.T) {
 	testCases := []struct {
 		name      string
@@ -195,6 +254,17 @@ func TestMaxMindConfigValidation(t *testing.T) {
 				LicenseKey:         "test-key",
 				AutoUpdate:         true,
 				AutoUpdateInterval: 24 * time.Hour,
+				Editions:           DefaultMaxMindEditions,
+				RetainVersions:     DefaultMaxMindRetainVersions,
+			},
+			expectErr: nil,
+		},
+		{
+			name: "commercial editions",
+			config: MaxMindConfig{
+				LicenseKey:     "test-key",
+				Editions:       []string{"GeoIP2-Enterprise", "GeoIP2-Anonymous-IP"},
+				RetainVersions: 1,
 			},
 			expectErr: nil,
 		},
@@ -216,6 +286,240 @@ func TestMaxMindConfigValidation(t *testing.T) {
 			},
 			expectErr: ErrMaxMindAutoUpdateIntervalInvalid,
 		},
+		{
+			name: "no retained versions",
+			config: MaxMindConfig{
+				LicenseKey: "test-key",
+				Editions:   DefaultMaxMindEditions,
+			},
+			expectErr: ErrMaxMindRetainVersionsInvalid,
+		},
+		{
+			name: "no editions",
+			config: MaxMindConfig{
+				LicenseKey:     "test-key",
+				RetainVersions: 1,
+			},
+			expectErr: ErrMaxMindEditionsEmpty,
+		},
+		{
+			name: "negative import watch interval",
+			config: MaxMindConfig{
+				LicenseKey:          "test-key",
+				Editions:            DefaultMaxMindEditions,
+				ImportWatchInterval: -time.Second,
+			},
+			expectErr: ErrMaxMindImportWatchIntervalInvalid,
+		},
+		{
+			name: "invalid canary",
+			config: MaxMindConfig{
+				LicenseKey:     "test-key",
+				Editions:       DefaultMaxMindEditions,
+				RetainVersions: 1,
+				Canaries:       []string{"8.8.8.8 -> US", "1.1.1.1 = AS13335"},
+			},
+			expectErr: ErrMaxMindCanaryInvalid,
+		},
+		{
+			name: "unsupported edition",
+			config: MaxMindConfig{
+				LicenseKey:     "test-key",
+				Editions:       []string{"GeoLite2-City", "GeoIP2-Precision"},
+				RetainVersions: 1,
+			},
+			expectErr: ErrMaxMindEditionInvalid,
+		},
+		{
+			name: "negative cache size",
+			config: MaxMindConfig{
+				LicenseKey:     "test-key",
+				Editions:       DefaultMaxMindEditions,
+				RetainVersions: 1,
+				CacheSize:      -1,
+			},
+			expectErr: ErrMaxMindCacheSizeInvalid,
+		},
+		{
+			name: "cache without ttl",
+			config: MaxMindConfig{
+				LicenseKey:     "test-key",
+				Editions:       DefaultMaxMindEditions,
+				RetainVersions: 1,
+				CacheSize:      100,
+			},
+			expectErr: ErrMaxMindCacheTTLInvalid,
+		},
+		{
+			name: "custom edition outside the data directory",
+			config: MaxMindConfig{
+				LicenseKey:     "test-key",
+				Editions:       DefaultMaxMindEditions,
+				RetainVersions: 1,
+				CustomEditions: []string{"../corp"},
+			},
+			expectErr: ErrMaxMindCustomEditionInvalid,
+		},
+		{
+			name: "custom edition named after a MaxMind edition",
+			config: MaxMindConfig{
+				LicenseKey:     "test-key",
+				Editions:       DefaultMaxMindEditions,
+				RetainVersions: 1,
+				CustomEditions: []string{"GeoLite2-City"},
+			},
+			expectErr: ErrMaxMindCustomEditionInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			assert.ErrorIs(t, err, tc.expectErr)
+		})
+	}
+}
+
+func TestParseMaxMindCanary(t *testing.T) {
+	testCases := []struct {
+		canary    string
+		expected  MaxMindCanary
+		expectErr error
+	}{
+		{canary: "8.8.8.8 -> US", expected: MaxMindCanary{IP: net.ParseIP("8.8.8.8"), CountryCode: "US"}},
+		{canary: "1.1.1.1->as13335", expected: MaxMindCanary{IP: net.ParseIP("1.1.1.1"), ASN: 13335}},
+		{canary: "2001:4860:4860::8888 -> us", expected: MaxMindCanary{IP: net.ParseIP("2001:4860:4860::8888"), CountryCode: "US"}},
+		{canary: "8.8.8.8 US", expectErr: ErrMaxMindCanaryInvalid},
+		{canary: "example.com -> US", expectErr: ErrMaxMindCanaryInvalid},
+		{canary: "8.8.8.8 -> USA", expectErr: ErrMaxMindCanaryInvalid},
+		{canary: "1.1.1.1 -> ASX", expectErr: ErrMaxMindCanaryInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.canary, func(t *testing.T) {
+			canary, err := ParseMaxMindCanary(tc.canary)
+			require.ErrorIs(t, err, tc.expectErr)
+			if tc.expectErr == nil {
+				assert.Equal(t, tc.expected, canary)
+			}
+		})
+	}
+}
+
+func TestLookupConfigValidation(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    LookupConfig
+		expectErr error
+	}{
+		{
+			name:      "valid config",
+			config:    LookupConfig{BatchLimit: 100},
+			expectErr: nil,
+		},
+		{
+			name:      "zero batch limit",
+			config:    LookupConfig{BatchLimit: 0},
+			expectErr: ErrLookupBatchLimitInvalid,
+		},
+		{
+			name:      "negative batch limit",
+			config:    LookupConfig{BatchLimit: -1},
+			expectErr: ErrLookupBatchLimitInvalid,
+		},
+		{
+			name:      "reject non-global addresses",
+			config:    LookupConfig{BatchLimit: 100, NonGlobal: NonGlobalReject},
+			expectErr: nil,
+		},
+		{
+			name:      "invalid non-global policy",
+			config:    LookupConfig{BatchLimit: 100, NonGlobal: "deny"},
+			expectErr: ErrLookupNonGlobalInvalid,
+		},
+		{
+			name:      "network prefix out of range",
+			config:    LookupConfig{BatchLimit: 100, NetworkMinPrefixIPv4: 33},
+			expectErr: ErrLookupNetworkPrefixInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestProviderConfigValidation(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    ProviderConfig
+		expectErr error
+	}{
+		{
+			name:      "maxmind provider",
+			config:    ProviderConfig{Type: ProviderTypeMaxMind},
+			expectErr: nil,
+		},
+		{
+			name: "mmdb provider with preset",
+			config: ProviderConfig{
+				Type: ProviderTypeMMDB,
+				MMDB: MMDBConfig{Databases: []MMDBDatabaseConfig{{File: "dbip-city-lite.mmdb", Preset: "geoip2-city"}}},
+			},
+			expectErr: nil,
+		},
+		{
+			name: "mmdb provider with field mapping",
+			config: ProviderConfig{
+				Type: ProviderTypeMMDB,
+				MMDB: MMDBConfig{Databases: []MMDBDatabaseConfig{{File: "ipinfo.mmdb", Fields: map[string]string{"country": "country"}}}},
+			},
+			expectErr: nil,
+		},
+		{
+			name:      "invalid provider type",
+			config:    ProviderConfig{Type: "unknown"},
+			expectErr: ErrProviderTypeInvalid,
+		},
+		{
+			name:      "mmdb provider without databases",
+			config:    ProviderConfig{Type: ProviderTypeMMDB},
+			expectErr: ErrMMDBDatabasesEmpty,
+		},
+		{
+			name: "mmdb database without file",
+			config: ProviderConfig{
+				Type: ProviderTypeMMDB,
+				MMDB: MMDBConfig{Databases: []MMDBDatabaseConfig{{Preset: "geoip2-city"}}},
+			},
+			expectErr: ErrMMDBDatabaseFileEmpty,
+		},
+		{
+			name: "mmdb database without fields",
+			config: ProviderConfig{
+				Type: ProviderTypeMMDB,
+				MMDB: MMDBConfig{Databases: []MMDBDatabaseConfig{{File: "dbip-city-lite.mmdb"}}},
+			},
+			expectErr: ErrMMDBDatabaseFieldsEmpty,
+		},
+		{
+			name: "negative reload interval",
+			config: ProviderConfig{
+				Type: ProviderTypeMMDB,
+				MMDB: MMDBConfig{
+					Databases:      []MMDBDatabaseConfig{{File: "dbip-city-lite.mmdb", Preset: "geoip2-city"}},
+					ReloadInterval: -time.Minute,
+				},
+			},
+			expectErr: ErrMMDBReloadIntervalInvalid,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -257,3 +561,66 @@ func TestServerConfigGetAddr(t *testing.T) {
 		})
 	}
 }
+
+func TestResolverConfigValidation(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    ResolverConfig
+		expectErr error
+	}{
+		{
+			name:      "system resolver",
+			config:    ResolverConfig{Timeout: time.Second, MaxRecords: 16, PTRTimeout: time.Second},
+			expectErr: nil,
+		},
+		{
+			name:      "custom server",
+			config:    ResolverConfig{Server: "1.1.1.1:53", Timeout: time.Second, MaxRecords: 16, PTRTimeout: time.Second},
+			expectErr: nil,
+		},
+		{
+			name:      "server without port",
+			config:    ResolverConfig{Server: "1.1.1.1", Timeout: time.Second, MaxRecords: 16},
+			expectErr: ErrResolverServerInvalid,
+		},
+		{
+			name:      "zero timeout",
+			config:    ResolverConfig{MaxRecords: 16},
+			expectErr: ErrResolverTimeoutInvalid,
+		},
+		{
+			name:      "zero max records",
+			config:    ResolverConfig{Timeout: time.Second},
+			expectErr: ErrResolverMaxRecordsInvalid,
+		},
+		{
+			name:      "zero ptr timeout",
+			config:    ResolverConfig{Timeout: time.Second, MaxRecords: 16},
+			expectErr: ErrResolverPTRTimeoutInvalid,
+		},
+		{
+			name:      "negative ptr cache size",
+			config:    ResolverConfig{Timeout: time.Second, MaxRecords: 16, PTRTimeout: time.Second, PTRCacheSize: -1},
+			expectErr: ErrResolverPTRCacheSizeInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestOIDCConfig_IsAdmin(t *testing.T) {
+	config := OIDCConfig{AdminGroups: []string{"waypoint-admins"}}
+	assert.True(t, config.IsAdmin([]string{"staff", "waypoint-admins"}))
+	assert.False(t, config.IsAdmin([]string{"staff"}))
+	assert.False(t, config.IsAdmin(nil))
+	assert.False(t, (&OIDCConfig{}).IsAdmin([]string{"waypoint-admins"}))
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrLookupBatchLimitInvalid indicates that the batch lookup limit is invalid.
+	ErrLookupBatchLimitInvalid = errors.New("lookup batch limit must be positive")
+
+	// ErrLookupNonGlobalInvalid indicates that the non-global address policy is invalid.
+	ErrLookupNonGlobalInvalid = errors.New("lookup non-global policy is invalid")
+
+	// ErrLookupNetworkPrefixInvalid indicates that the shortest network prefix accepted by network queries is invalid.
+	ErrLookupNetworkPrefixInvalid = errors.New("lookup network prefix length is out of range")
+)
+
+const (
+	// DefaultLookupBatchLimit is the default maximum number of IPs accepted by a single batch lookup request.
+	DefaultLookupBatchLimit = 100
+
+	// NonGlobalAllow classifies non-global addresses and returns them without geolocation data.
+	NonGlobalAllow = "allow"
+
+	// NonGlobalReject rejects lookups of non-global addresses.
+	NonGlobalReject = "reject"
+
+	// DefaultLookupNonGlobal is the default policy for lookups of non-global addresses.
+	DefaultLookupNonGlobal = NonGlobalAllow
+
+	// DefaultLookupNetworkMinPrefixIPv4 is the default shortest IPv4 prefix accepted by network queries.
+	DefaultLookupNetworkMinPrefixIPv4 = 16
+
+	// DefaultLookupNetworkMinPrefixIPv6 is the default shortest IPv6 prefix accepted by network queries.
+	DefaultLookupNetworkMinPrefixIPv6 = 32
+)
+
+const (
+	ipv4Bits = 32
+	ipv6Bits = 128
+)
+
+// LookupConfig holds IP lookup-related configuration.
+type LookupConfig struct {
+	BatchLimit int `mapstructure:"batch_limit"`
+	// NonGlobal is the policy for lookups of addresses that are not globally reachable, such as
+	// private, loopback or documentation addresses. Empty means allow.
+	NonGlobal string `mapstructure:"non_global"`
+	// NetworkMinPrefixIPv4 and NetworkMinPrefixIPv6 are the shortest prefixes, so the largest
+	// networks, that network queries walk.
+	NetworkMinPrefixIPv4 int `mapstructure:"network_min_prefix_ipv4"`
+	NetworkMinPrefixIPv6 int `mapstructure:"network_min_prefix_ipv6"`
+}
+
+// Validate checks if the lookup configuration is valid.
+func (l *LookupConfig) Validate() error {
+	if l.BatchLimit <= 0 {
+		return ErrLookupBatchLimitInvalid
+	}
+	switch l.NonGlobal {
+	case "", NonGlobalAllow, NonGlobalReject:
+	default:
+		return fmt.Errorf("%w: %q, must be %s or %s", ErrLookupNonGlobalInvalid, l.NonGlobal, NonGlobalAllow, NonGlobalReject)
+	}
+	if l.NetworkMinPrefixIPv4 < 0 || l.NetworkMinPrefixIPv4 > ipv4Bits {
+		return fmt.Errorf("%w: IPv4 /%d", ErrLookupNetworkPrefixInvalid, l.NetworkMinPrefixIPv4)
+	}
+	if l.NetworkMinPrefixIPv6 < 0 || l.NetworkMinPrefixIPv6 > ipv6Bits {
+		return fmt.Errorf("%w: IPv6 /%d", ErrLookupNetworkPrefixInvalid, l.NetworkMinPrefixIPv6)
+	}
+	return nil
+}
@@ -2,6 +2,12 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,21 +17,152 @@ var (
 
 	// ErrMaxMindAutoUpdateIntervalInvalid indicates that the MaxMind auto-update interval is invalid.
 	ErrMaxMindAutoUpdateIntervalInvalid = errors.New("MaxMind auto-update interval must be positive")
+
+	// ErrMaxMindImportWatchIntervalInvalid indicates that the MaxMind import watch interval is invalid.
+	ErrMaxMindImportWatchIntervalInvalid = errors.New("MaxMind import watch interval must not be negative")
+
+	// ErrMaxMindRetainVersionsInvalid indicates that the number of retained MaxMind database versions is invalid.
+	ErrMaxMindRetainVersionsInvalid = errors.New("MaxMind retained versions must be at least 1")
+
+	// ErrMaxMindCanaryInvalid indicates that a MaxMind canary lookup is malformed.
+	ErrMaxMindCanaryInvalid = errors.New("invalid MaxMind canary, expected \"<ip> -> <country code>\" or \"<ip> -> AS<number>\"")
+
+	// ErrMaxMindEditionsEmpty indicates that no MaxMind editions are configured.
+	ErrMaxMindEditionsEmpty = errors.New("at least one MaxMind edition is required")
+
+	// ErrMaxMindEditionInvalid indicates that a configured MaxMind edition is not supported.
+	ErrMaxMindEditionInvalid = errors.New("unsupported MaxMind edition")
+
+	// ErrMaxMindCacheSizeInvalid indicates that the MaxMind lookup cache size is invalid.
+	ErrMaxMindCacheSizeInvalid = errors.New("MaxMind cache size must not be negative")
+
+	// ErrMaxMindCacheTTLInvalid indicates that the MaxMind lookup cache TTL is invalid.
+	ErrMaxMindCacheTTLInvalid = errors.New("MaxMind cache TTL must be positive")
+
+	// ErrMaxMindCustomEditionInvalid indicates that a custom edition name is not usable as a file name
+	// or clashes with a MaxMind edition.
+	ErrMaxMindCustomEditionInvalid = errors.New("invalid MaxMind custom edition")
 )
 
+const maxMindCountryCodeLength = 2
+
+// customEditionPattern keeps custom edition names usable as file names in the data directory.
+var customEditionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
 const (
 	// DefaultMaxMindAutoUpdate is the default value for automatically updating the MaxMind GeoIP database.
 	DefaultMaxMindAutoUpdate = true
 
 	// DefaultMaxMindAutoUpdateInterval is the default interval for automatically updating the MaxMind GeoIP database.
 	DefaultMaxMindAutoUpdateInterval = 24 * time.Hour
+
+	// DefaultMaxMindImportWatchInterval is the default interval for checking the incoming directory for archives.
+	DefaultMaxMindImportWatchInterval = 30 * time.Second
+
+	// DefaultMaxMindRetainVersions is the default number of versions kept of each MaxMind edition.
+	DefaultMaxMindRetainVersions = 3
+
+	// DefaultMaxMindCacheSize is the default number of lookup results kept in memory.
+	DefaultMaxMindCacheSize = 100_000
+
+	// DefaultMaxMindCacheTTL is the default time a lookup result is kept in memory.
+	DefaultMaxMindCacheTTL = time.Hour
+
+	// DefaultMaxMindReverseIndex is the default value for indexing the networks by ASN and country.
+	DefaultMaxMindReverseIndex = true
+
+	// DefaultMaxMindDiffOnUpdate is the default value for comparing each new database version with the previous one.
+	DefaultMaxMindDiffOnUpdate = true
+
+	// DefaultMaxMindDiffDetail is the default value for writing every changed network of a diff report.
+	DefaultMaxMindDiffDetail = false
+)
+
+var (
+	// DefaultMaxMindEditions are the free GeoLite2 editions downloaded when none are configured.
+	DefaultMaxMindEditions = []string{"GeoLite2-Country", "GeoLite2-City", "GeoLite2-ASN"}
+
+	// SupportedMaxMindEditions lists the GeoLite2 and GeoIP2 editions that can be downloaded and looked up.
+	SupportedMaxMindEditions = []string{
+		"GeoLite2-Country",
+		"GeoLite2-City",
+		"GeoLite2-ASN",
+		"GeoIP2-Country",
+		"GeoIP2-City",
+		"GeoIP2-ISP",
+		"GeoIP2-Connection-Type",
+		"GeoIP2-Anonymous-IP",
+		"GeoIP2-Domain",
+		"GeoIP2-Enterprise",
+	}
 )
 
 // MaxMindConfig holds MaxMind GeoIP database-related configuration.
 type MaxMindConfig struct {
-	LicenseKey         string        `mapstructure:"license_key"`
-	AutoUpdate         bool          `mapstructure:"auto_update"`
-	AutoUpdateInterval time.Duration `mapstructure:"auto_update_interval"`
+	LicenseKey          string        `mapstructure:"license_key"`
+	AutoUpdate          bool          `mapstructure:"auto_update"`
+	AutoUpdateInterval  time.Duration `mapstructure:"auto_update_interval"`
+	Editions            []string      `mapstructure:"editions"`
+	ImportWatchInterval time.Duration `mapstructure:"import_watch_interval"`
+	RetainVersions      int           `mapstructure:"retain_versions"`
+	Canaries            []string      `mapstructure:"canaries"`
+	// CacheSize is the number of lookup results kept in memory. Zero disables the cache.
+	CacheSize int           `mapstructure:"cache_size"`
+	CacheTTL  time.Duration `mapstructure:"cache_ttl"`
+	// ReverseIndex indexes the networks by ASN and country when the server loads the databases.
+	ReverseIndex bool `mapstructure:"reverse_index"`
+	// CustomEditions are databases loaded from <data_dir>/<name>.mmdb next to the MaxMind editions,
+	// such as those built by waypoint mmdb build.
+	CustomEditions []string `mapstructure:"custom_editions"`
+	// DiffOnUpdate compares each new database version with the previous one and stores a report.
+	DiffOnUpdate bool `mapstructure:"diff_on_update"`
+	// DiffDetail writes every changed network of a diff report to an NDJSON file next to it.
+	DiffDetail bool `mapstructure:"diff_detail"`
+}
+
+// MaxMindCanary is a lookup that must return the expected country or ASN for a new database
+// to be accepted.
+type MaxMindCanary struct {
+	IP          net.IP
+	CountryCode string
+	ASN         uint
+}
+
+// String returns the canary in its configuration format.
+func (c MaxMindCanary) String() string {
+	if c.ASN != 0 {
+		return fmt.Sprintf("%s -> AS%d", c.IP, c.ASN)
+	}
+	return fmt.Sprintf("%s -> %s", c.IP, c.CountryCode)
+}
+
+// ParseMaxMindCanary parses a canary such as "8.8.8.8 -> US" or "1.1.1.1 -> AS13335".
+func ParseMaxMindCanary(s string) (MaxMindCanary, error) {
+	ipStr, expected, ok := strings.Cut(s, "->")
+	if !ok {
+		return MaxMindCanary{}, fmt.Errorf("%w: %s", ErrMaxMindCanaryInvalid, s)
+	}
+
+	canary := MaxMindCanary{IP: net.ParseIP(strings.TrimSpace(ipStr))}
+	if canary.IP == nil {
+		return MaxMindCanary{}, fmt.Errorf("%w: %s", ErrMaxMindCanaryInvalid, s)
+	}
+
+	expected = strings.ToUpper(strings.TrimSpace(expected))
+	if asn, found := strings.CutPrefix(expected, "AS"); found && asn != "" {
+		n, err := strconv.ParseUint(asn, 10, 32)
+		if err != nil {
+			return MaxMindCanary{}, fmt.Errorf("%w: %s", ErrMaxMindCanaryInvalid, s)
+		}
+		canary.ASN = uint(n)
+		return canary, nil
+	}
+
+	if len(expected) != maxMindCountryCodeLength {
+		return MaxMindCanary{}, fmt.Errorf("%w: %s", ErrMaxMindCanaryInvalid, s)
+	}
+	canary.CountryCode = expected
+	return canary, nil
 }
 
 // Validate checks if the MaxMind configuration is valid.
@@ -36,5 +173,35 @@ func (m *MaxMindConfig) Validate() error {
 	if m.AutoUpdate && m.AutoUpdateInterval <= 0 {
 		return ErrMaxMindAutoUpdateIntervalInvalid
 	}
+	if m.ImportWatchInterval < 0 {
+		return ErrMaxMindImportWatchIntervalInvalid
+	}
+	if m.RetainVersions < 1 {
+		return ErrMaxMindRetainVersionsInvalid
+	}
+	if m.CacheSize < 0 {
+		return ErrMaxMindCacheSizeInvalid
+	}
+	if m.CacheSize > 0 && m.CacheTTL <= 0 {
+		return ErrMaxMindCacheTTLInvalid
+	}
+	if len(m.Editions) == 0 {
+		return ErrMaxMindEditionsEmpty
+	}
+	for _, edition := range m.Editions {
+		if !slices.Contains(SupportedMaxMindEditions, edition) {
+			return fmt.Errorf("%w: %s", ErrMaxMindEditionInvalid, edition)
+		}
+	}
+	for _, edition := range m.CustomEditions {
+		if !customEditionPattern.MatchString(edition) || slices.Contains(SupportedMaxMindEditions, edition) {
+			return fmt.Errorf("%w: %s", ErrMaxMindCustomEditionInvalid, edition)
+		}
+	}
+	for _, canary := range m.Canaries {
+		if _, err := ParseMaxMindCanary(canary); err != nil {
+			return err
+		}
+	}
 	return nil
 }
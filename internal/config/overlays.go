@@ -0,0 +1,29 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrOverlaysRefreshIntervalInvalid indicates that the overlays refresh interval is invalid.
+	ErrOverlaysRefreshIntervalInvalid = errors.New("overlays refresh interval must not be negative")
+)
+
+const (
+	// DefaultOverlaysRefreshInterval is the default interval for reloading overlays from the database.
+	DefaultOverlaysRefreshInterval = time.Minute
+)
+
+// OverlaysConfig holds network overlay-related configuration.
+type OverlaysConfig struct {
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// Validate checks if the overlays configuration is valid.
+func (o *OverlaysConfig) Validate() error {
+	if o.RefreshInterval < 0 {
+		return ErrOverlaysRefreshIntervalInvalid
+	}
+	return nil
+}
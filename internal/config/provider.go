@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrProviderTypeInvalid indicates that the provider type is not supported.
+	ErrProviderTypeInvalid = errors.New("invalid provider type. Supported types are maxmind and mmdb")
+
+	// ErrMMDBDatabasesEmpty indicates that the mmdb provider has no databases configured.
+	ErrMMDBDatabasesEmpty = errors.New("mmdb provider requires at least one database")
+
+	// ErrMMDBDatabaseFileEmpty indicates that an mmdb provider database has no file configured.
+	ErrMMDBDatabaseFileEmpty = errors.New("mmdb provider database file is empty")
+
+	// ErrMMDBDatabaseFieldsEmpty indicates that an mmdb provider database has neither a preset nor a field mapping.
+	ErrMMDBDatabaseFieldsEmpty = errors.New("mmdb provider database requires a preset or a field mapping")
+
+	// ErrMMDBReloadIntervalInvalid indicates that the mmdb provider reload interval is invalid.
+	ErrMMDBReloadIntervalInvalid = errors.New("mmdb provider reload interval must not be negative")
+)
+
+// ProviderType represents the source of geolocation data.
+type ProviderType string
+
+const (
+	// ProviderTypeMaxMind serves lookups from MaxMind GeoIP2/GeoLite2 databases downloaded with a license key.
+	ProviderTypeMaxMind ProviderType = "maxmind"
+
+	// ProviderTypeMMDB serves lookups from arbitrary .mmdb files in the data directory using a field mapping.
+	ProviderTypeMMDB ProviderType = "mmdb"
+)
+
+const (
+	// DefaultProviderType is the default geolocation provider.
+	DefaultProviderType = ProviderTypeMaxMind
+)
+
+// ProviderConfig holds geolocation provider configuration.
+type ProviderConfig struct {
+	Type ProviderType `mapstructure:"type"`
+	MMDB MMDBConfig   `mapstructure:"mmdb"`
+}
+
+// Validate checks if the provider configuration is valid.
+func (p *ProviderConfig) Validate() error {
+	switch p.Type {
+	case ProviderTypeMaxMind:
+		return nil
+	case ProviderTypeMMDB:
+		return p.MMDB.Validate()
+	default:
+		return ErrProviderTypeInvalid
+	}
+}
+
+// MMDBConfig holds configuration of the generic mmdb provider.
+type MMDBConfig struct {
+	Databases      []MMDBDatabaseConfig `mapstructure:"databases"`
+	ReloadInterval time.Duration        `mapstructure:"reload_interval"`
+}
+
+// Validate checks if the mmdb provider configuration is valid.
+func (m *MMDBConfig) Validate() error {
+	if len(m.Databases) == 0 {
+		return ErrMMDBDatabasesEmpty
+	}
+	for _, db := range m.Databases {
+		if db.File == "" {
+			return ErrMMDBDatabaseFileEmpty
+		}
+		if db.Preset == "" && len(db.Fields) == 0 {
+			return ErrMMDBDatabaseFieldsEmpty
+		}
+	}
+	if m.ReloadInterval < 0 {
+		return ErrMMDBReloadIntervalInvalid
+	}
+	return nil
+}
+
+// MMDBDatabaseConfig describes a single .mmdb file and how its records map onto lookup fields.
+type MMDBDatabaseConfig struct {
+	// File is the database file name, relative to the data directory.
+	File string `mapstructure:"file"`
+	// Preset selects a built-in field mapping for a well known record layout.
+	Preset string `mapstructure:"preset"`
+	// Fields maps lookup fields to record paths and overrides the preset.
+	Fields map[string]string `mapstructure:"fields"`
+}
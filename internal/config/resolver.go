@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+var (
+	// ErrResolverServerInvalid indicates that the DNS server is not a host:port address.
+	ErrResolverServerInvalid = errors.New("resolver server must be a host:port address")
+
+	// ErrResolverTimeoutInvalid indicates that the resolution timeout is invalid.
+	ErrResolverTimeoutInvalid = errors.New("resolver timeout must be positive")
+
+	// ErrResolverMaxRecordsInvalid indicates that the maximum number of records is invalid.
+	ErrResolverMaxRecordsInvalid = errors.New("resolver max records must be positive")
+
+	// ErrResolverPTRTimeoutInvalid indicates that the reverse lookup timeout is invalid.
+	ErrResolverPTRTimeoutInvalid = errors.New("resolver ptr timeout must be positive")
+
+	// ErrResolverPTRCacheSizeInvalid indicates that the reverse lookup cache size is invalid.
+	ErrResolverPTRCacheSizeInvalid = errors.New("resolver ptr cache size must not be negative")
+)
+
+const (
+	// DefaultResolverTimeout is the default time limit for resolving a hostname.
+	DefaultResolverTimeout = 5 * time.Second
+
+	// DefaultResolverMaxRecords is the default maximum number of addresses looked up for a hostname.
+	DefaultResolverMaxRecords = 16
+
+	// DefaultResolverRefusePrivate is the default for refusing hostnames that resolve to non-global addresses.
+	DefaultResolverRefusePrivate = true
+
+	// DefaultResolverPTRTimeout is the default time limit for the reverse lookup of an address.
+	DefaultResolverPTRTimeout = time.Second
+
+	// DefaultResolverPTRCacheSize is the default number of reverse lookup results kept in memory.
+	DefaultResolverPTRCacheSize = 10000
+)
+
+// ResolverConfig holds DNS resolution-related configuration.
+type ResolverConfig struct {
+	// Server is the host:port of the DNS server to query. Empty uses the nameservers of the system.
+	Server        string        `mapstructure:"server"`
+	Timeout       time.Duration `mapstructure:"timeout"`
+	MaxRecords    int           `mapstructure:"max_records"`
+	RefusePrivate bool          `mapstructure:"refuse_private"`
+	// PTRTimeout limits each reverse lookup, so enriching results with PTR names stays fast.
+	PTRTimeout time.Duration `mapstructure:"ptr_timeout"`
+	// PTRCacheSize is the number of reverse lookup results cached until their TTL expires, 0 disables the cache.
+	PTRCacheSize int `mapstructure:"ptr_cache_size"`
+}
+
+// Validate checks if the resolver configuration is valid.
+func (r *ResolverConfig) Validate() error {
+	if r.Server != "" {
+		if _, _, err := net.SplitHostPort(r.Server); err != nil {
+			return fmt.Errorf("%w: %q", ErrResolverServerInvalid, r.Server)
+		}
+	}
+	if r.Timeout <= 0 {
+		return ErrResolverTimeoutInvalid
+	}
+	if r.MaxRecords <= 0 {
+		return ErrResolverMaxRecordsInvalid
+	}
+	if r.PTRTimeout <= 0 {
+		return ErrResolverPTRTimeoutInvalid
+	}
+	if r.PTRCacheSize < 0 {
+		return ErrResolverPTRCacheSizeInvalid
+	}
+	return nil
+}
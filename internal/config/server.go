@@ -2,7 +2,10 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"net"
+	"net/netip"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -20,8 +23,17 @@ var (
 
 	// ErrAPIListenPortInvalid is an alias for ErrInvalidPort for backwards compatibility.
 	ErrAPIListenPortInvalid = ErrInvalidPort
+
+	// ErrTrustedProxyInvalid indicates that a trusted proxy is not an IP address or CIDR.
+	ErrTrustedProxyInvalid = errors.New("invalid trusted proxy, expected an IP address or CIDR such as 10.0.0.0/8")
+
+	// ErrClientIPHeaderInvalid indicates that the client IP header is not supported.
+	ErrClientIPHeaderInvalid = errors.New("invalid client IP header")
 )
 
+// ClientIPHeaders are the forwarding headers the client address can be read from.
+var ClientIPHeaders = []string{"Forwarded", "X-Forwarded-For", "X-Real-IP", "CF-Connecting-IP", "True-Client-IP"}
+
 const (
 	// DefaultServerListenAddr is the default server listen address.
 	DefaultServerListenAddr = "0.0.0.0"
@@ -43,6 +55,9 @@ const (
 
 	// DefaultServerRequestTimeout is the default request timeout.
 	DefaultServerRequestTimeout = 60 * time.Second
+
+	// DefaultServerClientIPHeader is the default header the client address is read from.
+	DefaultServerClientIPHeader = "X-Forwarded-For"
 )
 
 // ServerConfig holds API server-related configuration.
@@ -57,6 +72,12 @@ type ServerConfig struct {
 	RequestTimeout time.Duration `mapstructure:"request_timeout"`
 	CertFile       string        `mapstructure:"cert_file"`
 	KeyFile        string        `mapstructure:"key_file"`
+	// TrustedProxies are the networks of the reverse proxies whose forwarding headers are believed
+	// when resolving the client address. Empty trusts no one.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// ClientIPHeader is the only forwarding header read from trusted proxies, the one they set or
+	// overwrite. Other forwarding headers may come from the client and are ignored.
+	ClientIPHeader string `mapstructure:"client_ip_header"`
 }
 
 // GetAddr returns the API server's listen address in "host:port" format.
@@ -64,9 +85,34 @@ func (s *ServerConfig) GetAddr() string {
 	return net.JoinHostPort(s.ListenAddr, strconv.Itoa(s.ListenPort))
 }
 
+// TrustedProxyPrefixes parses the trusted proxies. IP addresses are single-address networks.
+func (s *ServerConfig) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(s.TrustedProxies))
+	for _, proxy := range s.TrustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if addr, err := netip.ParseAddr(proxy); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrTrustedProxyInvalid, proxy)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
 // PostProcess performs post-processing on the server configuration.
 func (s *ServerConfig) PostProcess() {
 	s.BaseURL = strings.TrimSuffix(s.BaseURL, "/")
+
+	s.ClientIPHeader = strings.TrimSpace(s.ClientIPHeader)
+	for _, header := range ClientIPHeaders {
+		if strings.EqualFold(s.ClientIPHeader, header) {
+			s.ClientIPHeader = header
+		}
+	}
 }
 
 // Validate checks if the server configuration is valid.
@@ -82,5 +128,14 @@ func (s *ServerConfig) Validate() error {
 		}
 	}
 
+	if _, err := s.TrustedProxyPrefixes(); err != nil {
+		return err
+	}
+
+	if !slices.Contains(ClientIPHeaders, s.ClientIPHeader) {
+		return fmt.Errorf("%w: %q, expected one of %s", ErrClientIPHeaderInvalid, s.ClientIPHeader,
+			strings.Join(ClientIPHeaders, ", "))
+	}
+
 	return nil
 }
@@ -0,0 +1,5 @@
+-- Down Migration: Drop overlays table and indexes
+
+DROP INDEX IF EXISTS idx_overlays_network;
+
+DROP TABLE IF EXISTS overlays;
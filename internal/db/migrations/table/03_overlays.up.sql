@@ -0,0 +1,20 @@
+-- Up Migration: Create the overlays table
+
+CREATE TABLE overlays (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    network CIDR UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    country VARCHAR(255),
+    iso_country_code VARCHAR(2),
+    city VARCHAR(255),
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    asn BIGINT,
+    organization VARCHAR(255),
+    tags JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+-- Index for containment lookups
+CREATE INDEX idx_overlays_network ON overlays USING gist (network inet_ops);
@@ -0,0 +1,112 @@
+package overlays
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hibare/Waypoint/internal/maxmind"
+)
+
+// csvTagPrefix prefixes the columns holding tags, such as tags.team.
+const csvTagPrefix = "tags."
+
+// ErrInvalidOverlayCSV is returned when an overlay CSV file is malformed.
+var ErrInvalidOverlayCSV = errors.New("invalid overlay CSV")
+
+// ReadCSV reads overlays from a CSV file with a header row. The columns are named after the
+// overlay fields, network being required, and tags are given in columns such as tags.team.
+// Empty cells leave a field unset. Each overlay is validated as when it is created.
+func ReadCSV(r io.Reader) ([]maxmind.Overlay, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading header: %w", ErrInvalidOverlayCSV, err)
+	}
+	for i, column := range header {
+		header[i] = strings.TrimSpace(column)
+		switch column := header[i]; column {
+		case "network", "description", "country", "iso_country_code", "city", "latitude", "longitude", "asn", "organization":
+		default:
+			if !strings.HasPrefix(column, csvTagPrefix) || column == csvTagPrefix {
+				return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidOverlayCSV, column)
+			}
+		}
+	}
+
+	var result []maxmind.Overlay
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidOverlayCSV, err)
+		}
+
+		overlay, err := overlayFromRow(header, row)
+		if err == nil {
+			err = overlay.Validate()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidOverlayCSV, line, err)
+		}
+
+		o, err := overlay.ToMaxMind()
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidOverlayCSV, line, err)
+		}
+		result = append(result, o)
+	}
+	return result, nil
+}
+
+// overlayFromRow maps the cells of a CSV row to an overlay.
+func overlayFromRow(header, row []string) (*Overlay, error) {
+	overlay := &Overlay{Tags: map[string]string{}}
+	for i, column := range header {
+		value := strings.TrimSpace(row[i])
+		if value == "" {
+			continue
+		}
+
+		switch column {
+		case "network":
+			overlay.Network = value
+		case "description":
+			overlay.Description = value
+		case "country":
+			overlay.Country = &value
+		case "iso_country_code":
+			overlay.ISOCountryCode = &value
+		case "city":
+			overlay.City = &value
+		case "organization":
+			overlay.Organization = &value
+		case "latitude", "longitude":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, ErrInvalidOverlayCoordinates
+			}
+			if column == "latitude" {
+				overlay.Latitude = &f
+			} else {
+				overlay.Longitude = &f
+			}
+		case "asn":
+			asn, err := maxmind.ParseASN(value)
+			if err != nil {
+				return nil, err
+			}
+			overlay.ASN = &asn
+		default:
+			overlay.Tags[strings.TrimPrefix(column, csvTagPrefix)] = value
+		}
+	}
+	return overlay, nil
+}
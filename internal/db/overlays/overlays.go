@@ -0,0 +1,292 @@
+package overlays
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibare/Waypoint/internal/db"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const (
+	tableNameOverlays = "overlays"
+	maxNameLength     = 255
+	isoCodeLength     = 2
+	maxLatitude       = 90
+	maxLongitude      = 180
+
+	// pgUniqueViolation is the Postgres error code of a unique constraint violation.
+	pgUniqueViolation = "23505"
+)
+
+var (
+	// ErrOverlayNotFound is returned when the overlay is not found.
+	ErrOverlayNotFound = errors.New("overlay not found")
+
+	// ErrInvalidOverlayNetwork is returned when the overlay network is not a valid CIDR.
+	ErrInvalidOverlayNetwork = errors.New("invalid overlay network, expected a CIDR such as 10.0.0.0/8")
+
+	// ErrInvalidOverlayCountryCode is returned when the ISO country code is not two letters.
+	ErrInvalidOverlayCountryCode = errors.New("invalid overlay ISO country code")
+
+	// ErrInvalidOverlayCoordinates is returned when the latitude or longitude is out of range.
+	ErrInvalidOverlayCoordinates = errors.New("invalid overlay coordinates")
+
+	// ErrInvalidOverlayField is returned when a text field is too long.
+	ErrInvalidOverlayField = errors.New("overlay field exceeds 255 characters")
+
+	// ErrEmptyOverlay is returned when the overlay does not override any field.
+	ErrEmptyOverlay = errors.New("overlay must override at least one field")
+
+	// ErrDuplicateOverlayNetwork is returned when an overlay for the network already exists.
+	ErrDuplicateOverlayNetwork = errors.New("overlay for this network already exists")
+)
+
+// Overlay overrides lookup fields for the addresses of a network.
+type Overlay struct {
+	ID             uuid.UUID         `json:"id"                         gorm:"column:id;type:uuid;primaryKey"`
+	Network        string            `json:"network"                    gorm:"column:network;type:cidr;unique;not null"`
+	Description    string            `json:"description"                gorm:"column:description;type:text;not null;default:''"`
+	Country        *string           `json:"country,omitempty"          gorm:"column:country;type:varchar(255)"`
+	ISOCountryCode *string           `json:"iso_country_code,omitempty" gorm:"column:iso_country_code;type:varchar(2)"`
+	City           *string           `json:"city,omitempty"             gorm:"column:city;type:varchar(255)"`
+	Latitude       *float64          `json:"latitude,omitempty"         gorm:"column:latitude;type:double precision"`
+	Longitude      *float64          `json:"longitude,omitempty"        gorm:"column:longitude;type:double precision"`
+	ASN            *uint             `json:"asn,omitempty"              gorm:"column:asn;type:bigint"`
+	Organization   *string           `json:"organization,omitempty"     gorm:"column:organization;type:varchar(255)"`
+	Tags           map[string]string `json:"tags"                       gorm:"column:tags;type:jsonb;serializer:json;not null"`
+	CreatedAt      time.Time         `json:"created_at"                 gorm:"autoCreateTime;column:created_at;not null"`
+	UpdatedAt      time.Time         `json:"updated_at"                 gorm:"autoUpdateTime;column:updated_at;not null"`
+}
+
+func (o *Overlay) TableName() string {
+	return tableNameOverlays
+}
+
+func (o *Overlay) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+// Validate validates the overlay data and normalizes its network and country code.
+func (o *Overlay) Validate() error {
+	_, ipNet, err := net.ParseCIDR(strings.TrimSpace(o.Network))
+	if err != nil {
+		return ErrInvalidOverlayNetwork
+	}
+	o.Network = ipNet.String()
+
+	if o.ISOCountryCode != nil {
+		code := strings.ToUpper(strings.TrimSpace(*o.ISOCountryCode))
+		if len(code) != isoCodeLength || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+			return ErrInvalidOverlayCountryCode
+		}
+		o.ISOCountryCode = &code
+	}
+
+	if (o.Latitude == nil) != (o.Longitude == nil) {
+		return ErrInvalidOverlayCoordinates
+	}
+	if o.Latitude != nil && (math.IsNaN(*o.Latitude) || math.IsNaN(*o.Longitude) || *o.Latitude < -maxLatitude || *o.Latitude > maxLatitude ||
+		*o.Longitude < -maxLongitude || *o.Longitude > maxLongitude) {
+		return ErrInvalidOverlayCoordinates
+	}
+
+	for _, s := range []*string{o.Country, o.City, o.Organization} {
+		if s != nil && len(*s) > maxNameLength {
+			return ErrInvalidOverlayField
+		}
+	}
+
+	if o.Country == nil && o.ISOCountryCode == nil && o.City == nil && o.Latitude == nil &&
+		o.ASN == nil && o.Organization == nil && len(o.Tags) == 0 {
+		return ErrEmptyOverlay
+	}
+
+	if o.Tags == nil {
+		o.Tags = map[string]string{}
+	}
+
+	return nil
+}
+
+// ToMaxMind converts the overlay to its lookup representation.
+func (o *Overlay) ToMaxMind() (maxmind.Overlay, error) {
+	_, ipNet, err := net.ParseCIDR(o.Network)
+	if err != nil {
+		return maxmind.Overlay{}, err
+	}
+
+	return maxmind.Overlay{
+		Network:        ipNet,
+		Country:        o.Country,
+		ISOCountryCode: o.ISOCountryCode,
+		City:           o.City,
+		Latitude:       o.Latitude,
+		Longitude:      o.Longitude,
+		ASN:            o.ASN,
+		Organization:   o.Organization,
+		Tags:           o.Tags,
+	}, nil
+}
+
+// CreateOverlay creates a new overlay.
+func CreateOverlay(ctx context.Context, db *gorm.DB, overlay *Overlay) (*Overlay, error) {
+	if err := overlay.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := db.WithContext(ctx).Create(overlay).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	return overlay, nil
+}
+
+// ListOverlays lists overlays.
+func ListOverlays(ctx context.Context, tx *gorm.DB, params url.Values) ([]Overlay, error) {
+	var overlays []Overlay
+
+	qb := db.NewQueryBuilder()
+	qb.RegisterStringField("id")
+	qb.RegisterStringField("network")
+	qb.RegisterStringField("description")
+	qb.RegisterStringField("country")
+	qb.RegisterStringField("iso_country_code")
+	qb.RegisterStringField("city")
+	qb.RegisterIntField("asn")
+	qb.RegisterStringField("organization")
+	qb.RegisterTimeField("created_at")
+	qb.RegisterTimeField("updated_at")
+
+	// Parse URL query parameters into QueryOptions
+	opts, err := qb.ParseQueryParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.WithContext(ctx).
+		Scopes(qb.Scope(opts)).
+		Order("network").
+		Find(&overlays).Error
+
+	return overlays, err
+}
+
+// GetOverlay retrieves an overlay by its ID.
+func GetOverlay(ctx context.Context, db *gorm.DB, id string) (*Overlay, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrOverlayNotFound
+	}
+
+	var overlay Overlay
+	if err := db.WithContext(ctx).Where("id = ?", id).First(&overlay).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOverlayNotFound
+		}
+		return nil, err
+	}
+
+	return &overlay, nil
+}
+
+// UpdateOverlay replaces all fields of an existing overlay.
+func UpdateOverlay(ctx context.Context, db *gorm.DB, id string, overlay *Overlay) (*Overlay, error) {
+	existing, err := GetOverlay(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := overlay.Validate(); err != nil {
+		return nil, err
+	}
+	overlay.ID = existing.ID
+	overlay.CreatedAt = existing.CreatedAt
+
+	if err := db.WithContext(ctx).Save(overlay).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	return overlay, nil
+}
+
+// DeleteOverlay deletes an overlay.
+func DeleteOverlay(ctx context.Context, db *gorm.DB, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrOverlayNotFound
+	}
+
+	result := db.WithContext(ctx).
+		Where("id = ?", id).
+		Delete(&Overlay{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrOverlayNotFound
+	}
+
+	return nil
+}
+
+// LoadOverlays replaces the overlays of set with all overlays stored in the database.
+func LoadOverlays(ctx context.Context, db *gorm.DB, set *maxmind.OverlaySet) error {
+	var rows []Overlay
+	if err := db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return err
+	}
+
+	overlays := make([]maxmind.Overlay, 0, len(rows))
+	for _, row := range rows {
+		o, err := row.ToMaxMind()
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping invalid overlay", "id", row.ID, "network", row.Network, "error", err)
+			continue
+		}
+		overlays = append(overlays, o)
+	}
+
+	set.Replace(overlays)
+	return nil
+}
+
+// RunRefreshJob periodically reloads the overlays of set from the database, so changes made by
+// other instances sharing the database are picked up.
+func RunRefreshJob(ctx context.Context, db *gorm.DB, set *maxmind.OverlaySet, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	slog.InfoContext(ctx, "Scheduling overlay refresh job", "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Stopping overlay refresh job")
+			return
+		case <-ticker.C:
+			if err := LoadOverlays(ctx, db, set); err != nil {
+				slog.ErrorContext(ctx, "Overlay refresh failed", "error", err)
+			}
+		}
+	}
+}
+
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrDuplicateOverlayNetwork
+	}
+	return err
+}
@@ -0,0 +1,202 @@
+package overlays_test
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	dbpkg "github.com/hibare/Waypoint/internal/db"
+	"github.com/hibare/Waypoint/internal/db/overlays"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlay_Validate(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+
+	overlay := &overlays.Overlay{Network: " 10.1.2.3/16 ", ISOCountryCode: str("de")}
+	require.NoError(t, overlay.Validate())
+	assert.Equal(t, "10.1.0.0/16", overlay.Network)
+	assert.Equal(t, "DE", *overlay.ISOCountryCode)
+	assert.NotNil(t, overlay.Tags)
+
+	tests := []struct {
+		name    string
+		overlay overlays.Overlay
+		err     error
+	}{
+		{"invalid network", overlays.Overlay{Network: "10.0.0.1", City: str("Berlin")}, overlays.ErrInvalidOverlayNetwork},
+		{"invalid country code", overlays.Overlay{Network: "10.0.0.0/8", ISOCountryCode: str("DEU")}, overlays.ErrInvalidOverlayCountryCode},
+		{"latitude without longitude", overlays.Overlay{Network: "10.0.0.0/8", Latitude: num(52.5)}, overlays.ErrInvalidOverlayCoordinates},
+		{"latitude out of range", overlays.Overlay{Network: "10.0.0.0/8", Latitude: num(91), Longitude: num(13.4)}, overlays.ErrInvalidOverlayCoordinates},
+		{"latitude not a number", overlays.Overlay{Network: "10.0.0.0/8", Latitude: num(math.NaN()), Longitude: num(13.4)}, overlays.ErrInvalidOverlayCoordinates},
+		{"no fields", overlays.Overlay{Network: "2001:db8::/32"}, overlays.ErrEmptyOverlay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, tt.overlay.Validate(), tt.err)
+		})
+	}
+}
+
+func TestCreateOverlay(t *testing.T) {
+	db := testhelpers.SetupSharedTestDB(t)
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+
+	t.Run("successful creation", func(t *testing.T) {
+		overlay, err := overlays.CreateOverlay(ctx, db.DB, &overlays.Overlay{
+			Network:        "10.10.1.2/16",
+			ISOCountryCode: str("de"),
+			Tags:           map[string]string{"site": "ber1"},
+		})
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, overlay.ID)
+		assert.Equal(t, "10.10.0.0/16", overlay.Network)
+		assert.Equal(t, "DE", *overlay.ISOCountryCode)
+		assert.NotZero(t, overlay.CreatedAt)
+	})
+
+	t.Run("duplicate network", func(t *testing.T) {
+		_, err := overlays.CreateOverlay(ctx, db.DB, &overlays.Overlay{Network: "10.10.0.0/16", City: str("Berlin")})
+		require.ErrorIs(t, err, overlays.ErrDuplicateOverlayNetwork)
+	})
+
+	t.Run("invalid overlay", func(t *testing.T) {
+		_, err := overlays.CreateOverlay(ctx, db.DB, &overlays.Overlay{Network: "10.11.0.0", City: str("Berlin")})
+		require.ErrorIs(t, err, overlays.ErrInvalidOverlayNetwork)
+	})
+}
+
+func TestGetOverlay(t *testing.T) {
+	db := testhelpers.SetupSharedTestDB(t)
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+
+	created, err := overlays.CreateOverlay(ctx, db.DB, &overlays.Overlay{Network: "10.20.0.0/16", City: str("Paris")})
+	require.NoError(t, err)
+
+	t.Run("existing overlay", func(t *testing.T) {
+		overlay, err := overlays.GetOverlay(ctx, db.DB, created.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, "10.20.0.0/16", overlay.Network)
+		assert.Equal(t, "Paris", *overlay.City)
+		assert.Empty(t, overlay.Tags)
+	})
+
+	t.Run("non-existing overlay", func(t *testing.T) {
+		_, err := overlays.GetOverlay(ctx, db.DB, uuid.New().String())
+		require.ErrorIs(t, err, overlays.ErrOverlayNotFound)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		_, err := overlays.GetOverlay(ctx, db.DB, "not-a-uuid")
+		require.ErrorIs(t, err, overlays.ErrOverlayNotFound)
+	})
+}
+
+func TestListOverlays(t *testing.T) {
+	db := testhelpers.SetupSharedTestDB(t)
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+
+	_, err := overlays.CreateOverlay(ctx, db.DB, &overlays.Overlay{Network: "10.30.0.0/16", ISOCountryCode: str("SE")})
+	require.NoError(t, err)
+	_, err = overlays.CreateOverlay(ctx, db.DB, &overlays.Overlay{Network: "10.31.0.0/16", ISOCountryCode: str("NO")})
+	require.NoError(t, err)
+
+	t.Run("filter", func(t *testing.T) {
+		list, err := overlays.ListOverlays(ctx, db.DB, url.Values{"iso_country_code": {"SE"}})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, "10.30.0.0/16", list[0].Network)
+	})
+
+	t.Run("invalid field", func(t *testing.T) {
+		_, err := overlays.ListOverlays(ctx, db.DB, url.Values{"password": {"x"}})
+		require.ErrorIs(t, err, dbpkg.ErrInvalidField)
+	})
+}
+
+func TestUpdateOverlay(t *testing.T) {
+	db := testhelpers.SetupSharedTestDB(t)
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+
+	created, err := overlays.CreateOverlay(ctx, db.DB, &overlays.Overlay{Network: "10.40.0.0/16", City: str("Oslo")})
+	require.NoError(t, err)
+	_, err = overlays.CreateOverlay(ctx, db.DB, &overlays.Overlay{Network: "10.41.0.0/16", City: str("Bergen")})
+	require.NoError(t, err)
+
+	t.Run("replaces all fields", func(t *testing.T) {
+		updated, err := overlays.UpdateOverlay(ctx, db.DB, created.ID.String(), &overlays.Overlay{
+			Network:      "10.40.0.0/24",
+			Organization: str("Example Corp"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, updated.ID)
+
+		overlay, err := overlays.GetOverlay(ctx, db.DB, created.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, "10.40.0.0/24", overlay.Network)
+		assert.Nil(t, overlay.City)
+		assert.Equal(t, "Example Corp", *overlay.Organization)
+		assert.WithinDuration(t, created.CreatedAt, overlay.CreatedAt, time.Millisecond)
+	})
+
+	t.Run("duplicate network", func(t *testing.T) {
+		_, err := overlays.UpdateOverlay(ctx, db.DB, created.ID.String(), &overlays.Overlay{Network: "10.41.0.0/16", City: str("Oslo")})
+		require.ErrorIs(t, err, overlays.ErrDuplicateOverlayNetwork)
+	})
+
+	t.Run("non-existing overlay", func(t *testing.T) {
+		_, err := overlays.UpdateOverlay(ctx, db.DB, uuid.New().String(), &overlays.Overlay{Network: "10.42.0.0/16", City: str("Oslo")})
+		require.ErrorIs(t, err, overlays.ErrOverlayNotFound)
+	})
+}
+
+func TestDeleteOverlay(t *testing.T) {
+	db := testhelpers.SetupSharedTestDB(t)
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+
+	created, err := overlays.CreateOverlay(ctx, db.DB, &overlays.Overlay{Network: "10.50.0.0/16", City: str("Rome")})
+	require.NoError(t, err)
+
+	require.NoError(t, overlays.DeleteOverlay(ctx, db.DB, created.ID.String()))
+	_, err = overlays.GetOverlay(ctx, db.DB, created.ID.String())
+	require.ErrorIs(t, err, overlays.ErrOverlayNotFound)
+
+	require.ErrorIs(t, overlays.DeleteOverlay(ctx, db.DB, created.ID.String()), overlays.ErrOverlayNotFound)
+	require.ErrorIs(t, overlays.DeleteOverlay(ctx, db.DB, "not-a-uuid"), overlays.ErrOverlayNotFound)
+}
+
+func TestLoadOverlays(t *testing.T) {
+	db := testhelpers.SetupSharedTestDB(t)
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+
+	_, err := overlays.CreateOverlay(ctx, db.DB, &overlays.Overlay{
+		Network: "10.60.0.0/16",
+		City:    str("Madrid"),
+		Tags:    map[string]string{"site": "mad1"},
+	})
+	require.NoError(t, err)
+
+	set := maxmind.NewOverlaySet()
+	require.NoError(t, overlays.LoadOverlays(ctx, db.DB, set))
+
+	matched := set.Match(net.ParseIP("10.60.1.2"))
+	require.Len(t, matched, 1)
+	assert.Equal(t, "10.60.0.0/16", matched[0].Network.String())
+	assert.Equal(t, "Madrid", *matched[0].City)
+	assert.Equal(t, map[string]string{"site": "mad1"}, matched[0].Tags)
+	assert.Empty(t, set.Match(net.ParseIP("192.0.2.1")))
+}
@@ -0,0 +1,283 @@
+package maxmind
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ACLFormat is the file format of a generated access control list.
+type ACLFormat string
+
+const (
+	// ACLFormatNftables is an nftables table with an interval set per address family, for nft -f.
+	ACLFormatNftables ACLFormat = "nftables"
+	// ACLFormatIPSet is a hash:net set per address family, for ipset restore.
+	ACLFormatIPSet ACLFormat = "iptables-ipset"
+	// ACLFormatNginxGeo is an nginx geo block setting a variable to 1 for matching clients.
+	ACLFormatNginxGeo ACLFormat = "nginx-geo"
+	// ACLFormatHAProxyMap is an HAProxy map file mapping each network to 1, for map_ip.
+	ACLFormatHAProxyMap ACLFormat = "haproxy-map"
+	// ACLFormatCaddy is a Caddyfile named remote_ip matcher, for import.
+	ACLFormatCaddy ACLFormat = "caddy"
+	// ACLFormatPlainCIDR is one network per line.
+	ACLFormatPlainCIDR ACLFormat = "plain-cidr"
+
+	// DefaultACLName is the name of the generated sets, variables and matchers.
+	DefaultACLName = "waypoint"
+)
+
+// ACLFormats are the supported access control list formats.
+var ACLFormats = []ACLFormat{
+	ACLFormatNftables, ACLFormatIPSet, ACLFormatNginxGeo, ACLFormatHAProxyMap, ACLFormatCaddy, ACLFormatPlainCIDR,
+}
+
+// aclNamePattern keeps names valid in every format; ipset names are limited to 31 characters,
+// including the address family suffix.
+var aclNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,27}$`)
+
+// ACLFilter selects the networks of an access control list, those located in any of the countries
+// or announced by any of the ASNs.
+type ACLFilter struct {
+	Countries []string
+	ASNs      []uint
+}
+
+// ParseACLFormat parses the name of an access control list format.
+func ParseACLFormat(s string) (ACLFormat, error) {
+	format := ACLFormat(strings.ToLower(strings.TrimSpace(s)))
+	if !slices.Contains(ACLFormats, format) {
+		return "", fmt.Errorf("%w: %s", ErrUnknownACLFormat, s)
+	}
+	return format, nil
+}
+
+// Extension returns the conventional file extension of the format.
+func (f ACLFormat) Extension() string {
+	switch f {
+	case ACLFormatNftables:
+		return ".nft"
+	case ACLFormatIPSet:
+		return ".ipset"
+	case ACLFormatNginxGeo:
+		return ".conf"
+	case ACLFormatHAProxyMap:
+		return ".map"
+	case ACLFormatCaddy:
+		return ".caddy"
+	default:
+		return ".txt"
+	}
+}
+
+// ValidateACLName checks that a name can be used for the sets, variables and matchers of every format.
+func ValidateACLName(name string) error {
+	if !aclNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidACLName, name)
+	}
+	return nil
+}
+
+// ParseCountryCode parses an ISO 3166-1 alpha-2 country code, returning it in upper case.
+func ParseCountryCode(s string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(s))
+	if len(code) != 2 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidCountryCode, s)
+	}
+	return code, nil
+}
+
+// ParseCountryCodes parses a comma-separated list of ISO 3166-1 alpha-2 country codes.
+func ParseCountryCodes(s string) ([]string, error) {
+	var codes []string
+	for _, v := range strings.Split(s, ",") {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		code, err := ParseCountryCode(v)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// ParseASN parses an ASN given as a number, optionally prefixed with AS.
+func ParseASN(s string) (uint, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 2 && strings.EqualFold(s[:2], "AS") {
+		s = s[2:]
+	}
+	asn, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidASN, s)
+	}
+	return uint(asn), nil
+}
+
+// ParseASNs parses a comma-separated list of ASNs.
+func ParseASNs(s string) ([]uint, error) {
+	var asns []uint
+	for _, v := range strings.Split(s, ",") {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		asn, err := ParseASN(v)
+		if err != nil {
+			return nil, err
+		}
+		asns = append(asns, asn)
+	}
+	return asns, nil
+}
+
+// AggregatePrefixes returns the smallest set of networks covering the same addresses, merging
+// overlapping and adjacent networks, in address order.
+func AggregatePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	type addrRange struct{ first, last netip.Addr }
+
+	ranges := make([]addrRange, 0, len(prefixes))
+	for _, p := range prefixes {
+		p = p.Masked()
+		ranges = append(ranges, addrRange{p.Addr(), lastAddr(p)})
+	}
+	slices.SortFunc(ranges, func(a, b addrRange) int { return a.first.Compare(b.first) })
+
+	var merged []addrRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 {
+			cur := &merged[n-1]
+			// IPv4 addresses sort before IPv6 ones, so the families are never merged.
+			next := cur.last.Next()
+			if r.first.Compare(cur.last) <= 0 || (next.IsValid() && r.first == next) {
+				if r.last.Compare(cur.last) > 0 {
+					cur.last = r.last
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+
+	var result []netip.Prefix
+	for _, r := range merged {
+		result = append(result, rangeToPrefixes(r.first, r.last)...)
+	}
+	return result
+}
+
+// rangeToPrefixes returns the smallest set of networks covering the addresses first to last.
+func rangeToPrefixes(first, last netip.Addr) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for {
+		// The largest network starting at first that ends within the range.
+		var p netip.Prefix
+		for bits := 0; bits <= first.BitLen(); bits++ {
+			p = netip.PrefixFrom(first, bits)
+			if p.Masked().Addr() == first && lastAddr(p).Compare(last) <= 0 {
+				break
+			}
+		}
+		prefixes = append(prefixes, p)
+
+		end := lastAddr(p)
+		if end == last {
+			return prefixes
+		}
+		first = end.Next()
+	}
+}
+
+// lastAddr returns the last address of a network.
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().AsSlice()
+	for i := range b {
+		if hostBits := (i+1)*8 - p.Bits(); hostBits >= 8 {
+			b[i] = 0xff
+		} else if hostBits > 0 {
+			b[i] |= byte(1)<<hostBits - 1
+		}
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// WriteACL writes an access control list of the networks in the given format. The name is used
+// for the sets, variables and matchers of the format.
+func WriteACL(w io.Writer, format ACLFormat, name string, prefixes []netip.Prefix) error {
+	var v4, v6 []netip.Prefix
+	for _, p := range prefixes {
+		if p.Addr().Is4() {
+			v4 = append(v4, p)
+		} else {
+			v6 = append(v6, p)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	switch format {
+	case ACLFormatNftables:
+		fmt.Fprintf(bw, "table inet %s {\n", name)
+		writeNftablesSet(bw, name+"_ipv4", "ipv4_addr", v4)
+		writeNftablesSet(bw, name+"_ipv6", "ipv6_addr", v6)
+		fmt.Fprintln(bw, "}")
+	case ACLFormatIPSet:
+		writeIPSet(bw, name+"_v4", "inet", v4)
+		writeIPSet(bw, name+"_v6", "inet6", v6)
+	case ACLFormatNginxGeo:
+		fmt.Fprintf(bw, "geo $%s {\n\tdefault 0;\n", name)
+		for _, p := range prefixes {
+			fmt.Fprintf(bw, "\t%s 1;\n", p)
+		}
+		fmt.Fprintln(bw, "}")
+	case ACLFormatHAProxyMap:
+		for _, p := range prefixes {
+			fmt.Fprintf(bw, "%s 1\n", p)
+		}
+	case ACLFormatCaddy:
+		fmt.Fprintf(bw, "@%s {\n", name)
+		for _, p := range prefixes {
+			fmt.Fprintf(bw, "\tremote_ip %s\n", p)
+		}
+		fmt.Fprintln(bw, "}")
+	case ACLFormatPlainCIDR:
+		for _, p := range prefixes {
+			fmt.Fprintln(bw, p)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownACLFormat, format)
+	}
+	return bw.Flush()
+}
+
+func writeNftablesSet(w io.Writer, name, addrType string, prefixes []netip.Prefix) {
+	fmt.Fprintf(w, "\tset %s {\n\t\ttype %s\n\t\tflags interval\n", name, addrType)
+	// An empty element list is a syntax error, so it is left out.
+	if len(prefixes) > 0 {
+		fmt.Fprintln(w, "\t\telements = {")
+		for i, p := range prefixes {
+			sep := ","
+			if i == len(prefixes)-1 {
+				sep = ""
+			}
+			fmt.Fprintf(w, "\t\t\t%s%s\n", p, sep)
+		}
+		fmt.Fprintln(w, "\t\t}")
+	}
+	fmt.Fprintln(w, "\t}")
+}
+
+func writeIPSet(w io.Writer, name, family string, prefixes []netip.Prefix) {
+	// The default maximum of 65536 elements is too small for the larger countries.
+	fmt.Fprintf(w, "create %s hash:net family %s maxelem %d -exist\n", name, family, max(len(prefixes), 65536))
+	fmt.Fprintf(w, "flush %s\n", name)
+	for _, p := range prefixes {
+		fmt.Fprintf(w, "add %s %s\n", name, p)
+	}
+}
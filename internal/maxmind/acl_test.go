@@ -0,0 +1,172 @@
+package maxmind_test
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prefixes(t *testing.T, cidrs ...string) []netip.Prefix {
+	t.Helper()
+	result := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		result = append(result, netip.MustParsePrefix(cidr))
+	}
+	return result
+}
+
+func TestAggregatePrefixes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "adjacent halves",
+			input:    []string{"10.0.1.0/24", "10.0.0.0/24"},
+			expected: []string{"10.0.0.0/23"},
+		},
+		{
+			name:     "contained network",
+			input:    []string{"10.0.0.0/16", "10.0.5.0/24"},
+			expected: []string{"10.0.0.0/16"},
+		},
+		{
+			name:     "unaligned range",
+			input:    []string{"10.0.1.0/24", "10.0.2.0/23"},
+			expected: []string{"10.0.1.0/24", "10.0.2.0/23"},
+		},
+		{
+			name:     "adjacent unaligned ranges",
+			input:    []string{"10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24", "10.0.4.0/22"},
+			expected: []string{"10.0.1.0/24", "10.0.2.0/23", "10.0.4.0/22"},
+		},
+		{
+			name:     "families are not merged",
+			input:    []string{"2001:db8::/33", "255.255.255.0/24", "2001:db8:8000::/33", "::/128"},
+			expected: []string{"255.255.255.0/24", "::/128", "2001:db8::/32"},
+		},
+		{
+			name:     "host bits are masked",
+			input:    []string{"192.0.2.77/25"},
+			expected: []string{"192.0.2.0/25"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, prefixes(t, tt.expected...), maxmind.AggregatePrefixes(prefixes(t, tt.input...)))
+		})
+	}
+}
+
+func TestWriteACL(t *testing.T) {
+	networks := prefixes(t, "192.0.2.0/24", "2001:db8::/32")
+
+	tests := []struct {
+		format   maxmind.ACLFormat
+		expected string
+	}{
+		{
+			format: maxmind.ACLFormatNftables,
+			expected: "table inet edge {\n" +
+				"\tset edge_ipv4 {\n\t\ttype ipv4_addr\n\t\tflags interval\n\t\telements = {\n\t\t\t192.0.2.0/24\n\t\t}\n\t}\n" +
+				"\tset edge_ipv6 {\n\t\ttype ipv6_addr\n\t\tflags interval\n\t\telements = {\n\t\t\t2001:db8::/32\n\t\t}\n\t}\n" +
+				"}\n",
+		},
+		{
+			format: maxmind.ACLFormatIPSet,
+			expected: "create edge_v4 hash:net family inet maxelem 65536 -exist\nflush edge_v4\nadd edge_v4 192.0.2.0/24\n" +
+				"create edge_v6 hash:net family inet6 maxelem 65536 -exist\nflush edge_v6\nadd edge_v6 2001:db8::/32\n",
+		},
+		{
+			format:   maxmind.ACLFormatNginxGeo,
+			expected: "geo $edge {\n\tdefault 0;\n\t192.0.2.0/24 1;\n\t2001:db8::/32 1;\n}\n",
+		},
+		{
+			format:   maxmind.ACLFormatHAProxyMap,
+			expected: "192.0.2.0/24 1\n2001:db8::/32 1\n",
+		},
+		{
+			format:   maxmind.ACLFormatCaddy,
+			expected: "@edge {\n\tremote_ip 192.0.2.0/24\n\tremote_ip 2001:db8::/32\n}\n",
+		},
+		{
+			format:   maxmind.ACLFormatPlainCIDR,
+			expected: "192.0.2.0/24\n2001:db8::/32\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var b strings.Builder
+			require.NoError(t, maxmind.WriteACL(&b, tt.format, "edge", networks))
+			assert.Equal(t, tt.expected, b.String())
+		})
+	}
+
+	// Sets without networks are left empty rather than invalid
+	var b strings.Builder
+	require.NoError(t, maxmind.WriteACL(&b, maxmind.ACLFormatNftables, "edge", networks[:1]))
+	assert.Contains(t, b.String(), "\tset edge_ipv6 {\n\t\ttype ipv6_addr\n\t\tflags interval\n\t}\n")
+}
+
+func TestParseACLInputs(t *testing.T) {
+	format, err := maxmind.ParseACLFormat("NGINX-geo")
+	require.NoError(t, err)
+	assert.Equal(t, maxmind.ACLFormatNginxGeo, format)
+	_, err = maxmind.ParseACLFormat("pf")
+	require.ErrorIs(t, err, maxmind.ErrUnknownACLFormat)
+
+	asns, err := maxmind.ParseASNs("13335, AS209,as29518")
+	require.NoError(t, err)
+	assert.Equal(t, []uint{13335, 209, 29518}, asns)
+	_, err = maxmind.ParseASNs("AS")
+	require.ErrorIs(t, err, maxmind.ErrInvalidASN)
+	_, err = maxmind.ParseASNs("4294967296")
+	require.ErrorIs(t, err, maxmind.ErrInvalidASN)
+
+	countries, err := maxmind.ParseCountryCodes("de, fr,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DE", "FR"}, countries)
+	_, err = maxmind.ParseCountryCodes("DEU")
+	require.ErrorIs(t, err, maxmind.ErrInvalidCountryCode)
+
+	require.NoError(t, maxmind.ValidateACLName("edge_allow"))
+	require.ErrorIs(t, maxmind.ValidateACLName("edge-allow"), maxmind.ErrInvalidACLName)
+	require.ErrorIs(t, maxmind.ValidateACLName(strings.Repeat("a", 29)), maxmind.ErrInvalidACLName)
+}
+
+func TestClient_ACLNetworks(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	defer client.Close()
+
+	filter := maxmind.ACLFilter{Countries: []string{"SE"}, ASNs: []uint{29518}}
+
+	// Without the reverse index, the databases are walked
+	require.NoError(t, client.Load())
+	walked, err := client.ACLNetworks(filter)
+	require.NoError(t, err)
+
+	client.EnableReverseIndex()
+	require.NoError(t, client.Load())
+
+	// The SE networks of the Country edition lie within the network of AS29518, so they are merged
+	networks, err := client.ACLNetworks(filter)
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("89.160.0.0/17"), networks[0])
+	assert.True(t, networks[1].Addr().Is6())
+	assert.Equal(t, networks, walked)
+
+	_, err = client.ACLNetworks(maxmind.ACLFilter{})
+	require.ErrorIs(t, err, maxmind.ErrACLFilterRequired)
+
+	_, err = client.ACLNetworks(maxmind.ACLFilter{Countries: []string{"ZZ"}})
+	require.ErrorIs(t, err, maxmind.ErrACLEmpty)
+}
@@ -0,0 +1,162 @@
+package maxmind
+
+import (
+	"container/list"
+	"hash/maphash"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cacheShards is the number of independently locked parts of the lookup cache.
+const cacheShards = 16
+
+// CacheStats are the counters of the lookup cache.
+type CacheStats struct {
+	Entries   int    `json:"entries"`
+	Capacity  int    `json:"capacity"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// lookupCache is a bounded LRU cache of lookup results, sharded to keep lock contention low under
+// concurrent lookups. Entries expire after the TTL.
+type lookupCache struct {
+	shards [cacheShards]cacheShard
+	seed   maphash.Seed
+	ttl    time.Duration
+	now    func() time.Time
+
+	// generation is bumped on every flush, so results looked up in the old databases are not stored.
+	generation atomic.Uint64
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+	evictions  atomic.Uint64
+}
+
+type cacheShard struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	lru      *list.List
+}
+
+type cacheEntry struct {
+	key     string
+	geoIP   GeoIP
+	expires time.Time
+}
+
+// newLookupCache creates a cache holding up to size results, or returns nil if size is not positive.
+func newLookupCache(size int, ttl time.Duration) *lookupCache {
+	if size <= 0 {
+		return nil
+	}
+
+	c := &lookupCache{seed: maphash.MakeSeed(), ttl: ttl, now: time.Now}
+	for i := range c.shards {
+		// Spread the capacity over the shards, rounding up so small caches still hold something.
+		c.shards[i].capacity = (size + cacheShards - 1) / cacheShards
+		c.shards[i].entries = make(map[string]*list.Element)
+		c.shards[i].lru = list.New()
+	}
+	return c
+}
+
+func (c *lookupCache) shard(key string) *cacheShard {
+	return &c.shards[maphash.String(c.seed, key)%cacheShards]
+}
+
+// get returns the cached result for key and the current generation, to be passed to put.
+func (c *lookupCache) get(key string) (GeoIP, uint64, bool) {
+	generation := c.generation.Load()
+	s := c.shard(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		c.misses.Add(1)
+		return GeoIP{}, generation, false
+	}
+	entry := elem.Value.(*cacheEntry) //nolint:errcheck // the list only holds cache entries
+	if c.now().After(entry.expires) {
+		s.lru.Remove(elem)
+		delete(s.entries, key)
+		c.misses.Add(1)
+		return GeoIP{}, generation, false
+	}
+
+	s.lru.MoveToFront(elem)
+	c.hits.Add(1)
+	return cloneGeoIP(entry.geoIP), generation, true
+}
+
+// put stores a result looked up at generation, unless the cache has been flushed since.
+func (c *lookupCache) put(key string, generation uint64, geoIP GeoIP) {
+	s := c.shard(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.generation.Load() != generation {
+		return
+	}
+
+	entry := &cacheEntry{key: key, geoIP: cloneGeoIP(geoIP), expires: c.now().Add(c.ttl)}
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.lru.MoveToFront(elem)
+		return
+	}
+
+	s.entries[key] = s.lru.PushFront(entry)
+	for s.lru.Len() > s.capacity {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*cacheEntry).key) //nolint:errcheck // the list only holds cache entries
+		c.evictions.Add(1)
+	}
+}
+
+// flush removes all entries.
+func (c *lookupCache) flush() {
+	c.generation.Add(1)
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.entries = make(map[string]*list.Element)
+		s.lru.Init()
+		s.mu.Unlock()
+	}
+}
+
+// stats returns the current counters of the cache.
+func (c *lookupCache) stats() CacheStats {
+	stats := CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		stats.Entries += s.lru.Len()
+		stats.Capacity += s.capacity
+		s.mu.Unlock()
+	}
+	return stats
+}
+
+// cloneGeoIP copies the maps and slices of a result, so callers cannot modify a cached result.
+func cloneGeoIP(geoIP GeoIP) GeoIP {
+	geoIP.Networks = maps.Clone(geoIP.Networks)
+	geoIP.Subdivisions = slices.Clone(geoIP.Subdivisions)
+	geoIP.Tags = maps.Clone(geoIP.Tags)
+	geoIP.Warnings = slices.Clone(geoIP.Warnings)
+	return geoIP
+}
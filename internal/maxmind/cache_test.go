@@ -0,0 +1,85 @@
+package maxmind_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_LookupCache(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{CacheSize: 100, CacheTTL: time.Hour}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	geo, err := client.IP2Geo("81.2.69.142")
+	require.NoError(t, err)
+	geo.Networks["GeoLite2-City"] = "modified"
+
+	cached, err := client.IP2Geo("81.2.69.142")
+	require.NoError(t, err)
+	assert.Equal(t, "London", cached.City)
+	assert.Equal(t, "81.2.69.142/31", cached.Networks["GeoLite2-City"], "callers must not modify cached results")
+
+	// Results are cached per language
+	cached, err = client.IP2Geo("81.2.69.142", maxmind.WithLanguage("zh-CN"))
+	require.NoError(t, err)
+	assert.Equal(t, "英国", cached.Country)
+
+	// Errors are not cached
+	_, err = client.IP2Geo("invalid")
+	require.Error(t, err)
+
+	stats, ok := client.CacheStats()
+	require.True(t, ok)
+	assert.Equal(t, maxmind.CacheStats{Entries: 2, Capacity: 112, Hits: 1, Misses: 3}, stats)
+
+	// Reloading the databases flushes the cache
+	require.NoError(t, client.Load())
+	stats, _ = client.CacheStats()
+	assert.Zero(t, stats.Entries)
+}
+
+func TestClient_LookupCacheEviction(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{CacheSize: 1, CacheTTL: time.Hour}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	// More distinct lookups than the cache holds in total
+	stats, _ := client.CacheStats()
+	for i := range stats.Capacity + 1 {
+		_, err := client.IP2Geo(fmt.Sprintf("81.2.69.%d", 160+i))
+		require.NoError(t, err)
+	}
+
+	stats, _ = client.CacheStats()
+	assert.Positive(t, stats.Evictions)
+	assert.Equal(t, uint64(stats.Capacity+1), uint64(stats.Entries)+stats.Evictions)
+}
+
+func TestClient_LookupCacheExpiry(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{CacheSize: 100, CacheTTL: 10 * time.Millisecond}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	_, err := client.IP2Geo("81.2.69.142")
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = client.IP2Geo("81.2.69.142")
+	require.NoError(t, err)
+
+	stats, _ := client.CacheStats()
+	assert.Zero(t, stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+}
+
+func TestClient_LookupCacheDisabled(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	_, ok := client.CacheStats()
+	assert.False(t, ok)
+}
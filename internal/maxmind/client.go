@@ -5,31 +5,75 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hibare/Waypoint/internal/config"
-	"github.com/oschwald/geoip2-golang"
+	"github.com/oschwald/maxminddb-golang"
 )
 
 // Client handles MaxMind database operations and lookups.
 type Client struct {
-	config  *config.MaxMindConfig
-	dataDir string
-	readers map[DBType]*geoip2.Reader
-	mu      sync.RWMutex
+	config   *config.MaxMindConfig
+	dataDir  string
+	readers  map[DBType]*maxminddb.Reader
+	loadedAt map[DBType]time.Time
+	cache    *lookupCache
+	mu       sync.RWMutex
+
+	indexEnabled atomic.Bool
+	index        atomic.Pointer[reverseIndex]
+	// indexMu serializes index builds, so an older build cannot replace a newer one. Walks of
+	// the readers run under it instead of mu, and replaced readers are only closed under it.
+	indexMu sync.Mutex
+
+	// diffs tracks the comparisons of updated versions running in the background.
+	diffs sync.WaitGroup
 }
 
 // NewClient creates a new MaxMind client.
 func NewClient(cfg *config.MaxMindConfig, dataDir string) *Client {
 	return &Client{
-		config:  cfg,
-		dataDir: dataDir,
-		readers: make(map[DBType]*geoip2.Reader),
+		config:   cfg,
+		dataDir:  dataDir,
+		readers:  make(map[DBType]*maxminddb.Reader),
+		loadedAt: make(map[DBType]time.Time),
+		cache:    newLookupCache(cfg.CacheSize, cfg.CacheTTL),
+	}
+}
+
+// Name returns the name of the provider.
+func (c *Client) Name() string {
+	return string(config.ProviderTypeMaxMind)
+}
+
+// Metadata returns the metadata of the loaded databases.
+func (c *Client) Metadata() []DBMetadata {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	metadata := make([]DBMetadata, 0, len(c.readers))
+	for t, reader := range c.readers {
+		m := newDBMetadata(string(t), c.getDBPath(t), reader, c.loadedAt[t])
+		if status, err := c.readUpdateStatus(t); err == nil {
+			m.LastUpdate = status
+		}
+		metadata = append(metadata, m)
 	}
+	slices.SortFunc(metadata, func(a, b DBMetadata) int { return strings.Compare(a.Name, b.Name) })
+
+	return metadata
 }
 
-// Close closes all open database readers.
+// Close waits for the comparisons of updated versions and closes all open database readers.
 func (c *Client) Close() {
+	c.diffs.Wait()
+
+	c.indexMu.Lock()
+	defer c.indexMu.Unlock()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -38,37 +82,102 @@ func (c *Client) Close() {
 			_ = reader.Close()
 		}
 	}
-	c.readers = make(map[DBType]*geoip2.Reader)
+	c.readers = make(map[DBType]*maxminddb.Reader)
+	c.loadedAt = make(map[DBType]time.Time)
+	c.flushCache()
+	c.index.Store(nil)
 }
 
-// Load loads all databases from disk.
+// Load loads all configured editions from disk, flushes the lookup cache and rebuilds the
+// reverse index, if enabled.
 func (c *Client) Load() error {
+	// A failed load may still have swapped some readers, so the index is rebuilt either way.
+	replaced, err := c.load()
+	c.rebuildIndex()
+	c.closeReaders(replaced)
+	return err
+}
+
+// load opens all configured editions and swaps them in. It returns the replaced readers, which
+// may still be walked and are closed by the caller.
+func (c *Client) load() ([]*maxminddb.Reader, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	// Flushed while still holding the lock, so no lookup can see the new databases before
+	// results from the old ones are gone.
+	defer c.flushCache()
 
-	types := []DBType{DBTypeCountry, DBTypeCity, DBTypeASN}
-
-	for _, t := range types {
+	var replaced []*maxminddb.Reader
+	for _, t := range slices.Concat(c.editions(), c.customEditions()) {
 		path := c.getDBPath(t)
 		if _, err := os.Stat(path); os.IsNotExist(err) {
 			slog.Warn("Database file not found, skipping load", "type", t, "path", path)
 			continue
 		}
 
-		reader, err := geoip2.Open(path)
+		reader, err := maxminddb.Open(path)
 		if err != nil {
-			return fmt.Errorf("%w: type=%s path=%s err=%w", ErrDBOpenFailed, t, path, err)
+			return replaced, fmt.Errorf("%w: type=%s path=%s err=%w", ErrDBOpenFailed, t, path, err)
 		}
 
 		if oldReader, ok := c.readers[t]; ok && oldReader != nil {
-			_ = oldReader.Close()
+			replaced = append(replaced, oldReader)
 		}
 
 		c.readers[t] = reader
+		c.loadedAt[t] = time.Now().UTC()
 		slog.Info("Loaded database", "type", t, "path", path)
 	}
 
-	return nil
+	return replaced, nil
+}
+
+// closeReaders closes replaced readers once no walk of them is running.
+func (c *Client) closeReaders(readers []*maxminddb.Reader) {
+	c.indexMu.Lock()
+	defer c.indexMu.Unlock()
+
+	for _, reader := range readers {
+		_ = reader.Close()
+	}
+}
+
+// CacheStats returns the counters of the lookup cache. It reports false if the cache is disabled.
+func (c *Client) CacheStats() (CacheStats, bool) {
+	if c.cache == nil {
+		return CacheStats{}, false
+	}
+	return c.cache.stats(), true
+}
+
+func (c *Client) flushCache() {
+	if c.cache != nil {
+		c.cache.flush()
+	}
+}
+
+// editions returns the configured editions, falling back to the free GeoLite2 editions.
+func (c *Client) editions() []DBType {
+	editions := c.config.Editions
+	if len(editions) == 0 {
+		editions = config.DefaultMaxMindEditions
+	}
+
+	types := make([]DBType, 0, len(editions))
+	for _, e := range editions {
+		types = append(types, DBType(e))
+	}
+	return types
+}
+
+// customEditions returns the configured custom editions, databases built by Waypoint or others
+// that are loaded next to the MaxMind editions.
+func (c *Client) customEditions() []DBType {
+	types := make([]DBType, 0, len(c.config.CustomEditions))
+	for _, e := range c.config.CustomEditions {
+		types = append(types, DBType(e))
+	}
+	return types
 }
 
 // getDBPath returns the full path for a database file.
@@ -0,0 +1,99 @@
+package maxmind
+
+import (
+	"net/netip"
+	"slices"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// ACLNetworks returns the aggregated networks selected by filter, in address order. They are
+// taken from the reverse index, or found by walking the databases if the index is not built.
+func (c *Client) ACLNetworks(filter ACLFilter) ([]netip.Prefix, error) {
+	if len(filter.Countries) == 0 && len(filter.ASNs) == 0 {
+		return nil, ErrACLFilterRequired
+	}
+
+	c.mu.RLock()
+	hasCountries := c.countryReader() != nil
+	hasASNs := c.loadedReader(asnDBTypes) != nil
+	c.mu.RUnlock()
+	if len(filter.Countries) > 0 && !hasCountries {
+		return nil, ErrCountryDBNotLoaded
+	}
+	if len(filter.ASNs) > 0 && !hasASNs {
+		return nil, ErrASNDBNotLoaded
+	}
+
+	var prefixes []netip.Prefix
+	if idx := c.index.Load(); idx != nil {
+		for _, code := range filter.Countries {
+			prefixes = append(prefixes, idx.countries[code]...)
+		}
+		for _, asn := range filter.ASNs {
+			if entry, ok := idx.asns[asn]; ok {
+				prefixes = append(prefixes, entry.networks...)
+			}
+		}
+	} else {
+		var err error
+		if prefixes, err = c.walkACLNetworks(filter); err != nil {
+			return nil, err
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil, ErrACLEmpty
+	}
+	return AggregatePrefixes(prefixes), nil
+}
+
+// walkACLNetworks walks the first loaded ASN and Country (or City) editions for the networks
+// selected by filter. Like an index build, the readers are walked under indexMu, without
+// holding mu.
+func (c *Client) walkACLNetworks(filter ACLFilter) ([]netip.Prefix, error) {
+	c.indexMu.Lock()
+	defer c.indexMu.Unlock()
+
+	c.mu.RLock()
+	asnReader := c.loadedReader(asnDBTypes)
+	countryReader := c.countryReader()
+	c.mu.RUnlock()
+
+	var prefixes []netip.Prefix
+	if len(filter.ASNs) > 0 && asnReader != nil {
+		networks := asnReader.Networks(maxminddb.SkipAliasedNetworks)
+		for networks.Next() {
+			var record geoip2.ASN
+			network, err := networks.Network(&record)
+			if err != nil {
+				return nil, err
+			}
+			if slices.Contains(filter.ASNs, record.AutonomousSystemNumber) {
+				prefixes = append(prefixes, toPrefix(network))
+			}
+		}
+		if err := networks.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(filter.Countries) > 0 && countryReader != nil {
+		networks := countryReader.Networks(maxminddb.SkipAliasedNetworks)
+		for networks.Next() {
+			var record countryRecord
+			network, err := networks.Network(&record)
+			if err != nil {
+				return nil, err
+			}
+			if slices.Contains(filter.Countries, record.Country.IsoCode) {
+				prefixes = append(prefixes, toPrefix(network))
+			}
+		}
+		if err := networks.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return prefixes, nil
+}
@@ -0,0 +1,121 @@
+package maxmind
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// pendingDiff is a comparison of a new version of an edition with the version it replaced. Both
+// versions are opened up front, so pruning or rolling back versions cannot remove them from under
+// the comparison.
+type pendingDiff struct {
+	dbType            DBType
+	previous, current uint
+	older, newer      *maxminddb.Reader
+}
+
+// close closes the readers of both versions.
+func (d *pendingDiff) close() {
+	_ = d.older.Close()
+	_ = d.newer.Close()
+}
+
+// openDiff opens the versions of an edition to compare. It returns nil if there is nothing to
+// compare or a version cannot be opened.
+func (c *Client) openDiff(dbType DBType, previous, current uint) *pendingDiff {
+	if previous == 0 || previous == current {
+		return nil
+	}
+	oldPath := c.getVersionPath(dbType, previous)
+	if _, err := os.Stat(oldPath); err != nil {
+		slog.Warn("Previous DB version not retained, skipping diff", "type", dbType, "version", previous)
+		return nil
+	}
+
+	older, err := maxminddb.Open(oldPath)
+	if err != nil {
+		slog.Error("Error opening DB version to compare", "type", dbType, "version", previous, "error", err)
+		return nil
+	}
+	newer, err := maxminddb.Open(c.getVersionPath(dbType, current))
+	if err != nil {
+		_ = older.Close()
+		slog.Error("Error opening DB version to compare", "type", dbType, "version", current, "error", err)
+		return nil
+	}
+	return &pendingDiff{dbType: dbType, previous: previous, current: current, older: older, newer: newer}
+}
+
+// writeDiff runs a pending comparison and stores the report, and the detail if enabled, next to
+// the new version. The report is written last, so the detail is complete once the report exists.
+// Failures are logged, as the report does not affect the update, and leave no temporary files
+// behind. The comparison stops when ctx is done.
+func (c *Client) writeDiff(ctx context.Context, d *pendingDiff) {
+	defer d.close()
+
+	var detail *os.File
+	detailPath := c.getDiffPath(d.dbType, d.current, DiffDetailSuffix)
+	if c.config.DiffDetail {
+		f, err := os.Create(detailPath + ".tmp")
+		if err != nil {
+			slog.Error("Error creating DB diff detail", "type", d.dbType, "error", err)
+			return
+		}
+		detail = f
+	}
+	// discardDetail removes the unfinished detail on the error paths.
+	discardDetail := func() {
+		if detail != nil {
+			_ = detail.Close()
+			_ = os.Remove(detailPath + ".tmp")
+		}
+	}
+
+	var w io.Writer
+	if detail != nil {
+		w = detail
+	}
+	report, err := diffReaders(ctx, d.older, d.newer, w)
+	if err != nil {
+		discardDetail()
+		slog.Error("Error comparing DB versions", "type", d.dbType, "old", d.previous, "new", d.current, "error", err)
+		return
+	}
+
+	b, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		discardDetail()
+		slog.Error("Error encoding DB diff", "type", d.dbType, "error", err)
+		return
+	}
+	if detail != nil {
+		err := detail.Close()
+		if err == nil {
+			err = os.Rename(detailPath+".tmp", detailPath)
+		}
+		if err != nil {
+			_ = os.Remove(detailPath + ".tmp")
+			slog.Error("Error writing DB diff detail", "type", d.dbType, "error", err)
+			return
+		}
+	}
+
+	reportPath := c.getDiffPath(d.dbType, d.current, DiffReportSuffix)
+	err = os.WriteFile(reportPath+".tmp", b, 0o600)
+	if err == nil {
+		err = os.Rename(reportPath+".tmp", reportPath)
+	}
+	if err != nil {
+		_ = os.Remove(reportPath + ".tmp")
+		slog.Error("Error writing DB diff", "type", d.dbType, "error", err)
+		return
+	}
+
+	slog.Info("DB changes since previous version", "type", d.dbType, "old", d.previous, "new", d.current,
+		"added", report.Added, "removed", report.Removed, "changed", report.Changed, "report", reportPath)
+}
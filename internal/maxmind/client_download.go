@@ -2,9 +2,11 @@ package maxmind
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,60 +18,111 @@ import (
 
 const minSHA256FileParts = 2
 
-// RunDBDownloadJob starts the background job for downloading databases.
-func (c *Client) RunDBDownloadJob(ctx context.Context) {
-	ticker := time.NewTicker(c.config.AutoUpdateInterval)
-	slog.InfoContext(ctx, "Scheduling DB update job")
-
-	for {
-		select {
-		case <-ctx.Done():
-			slog.InfoContext(ctx, "Stopping DB update job")
-			return
-		case <-ticker.C:
-			if err := c.DownloadAllDB(); err != nil {
-				slog.ErrorContext(ctx, "Background DB update failed", "error", err)
-			}
-		}
-	}
+// Update downloads all configured databases and reloads them. Updated versions are compared with
+// the previous ones in the background, until ctx is done.
+func (c *Client) Update(ctx context.Context) error {
+	return c.downloadAllDB(ctx)
 }
 
-// DownloadAllDB downloads all configured databases.
+// DownloadAllDB downloads all configured databases, and waits for the comparisons of the updated
+// versions.
 func (c *Client) DownloadAllDB() error {
-	slog.Info("Downloading all DB files")
+	defer c.diffs.Wait()
+	return c.downloadAllDB(context.Background())
+}
 
-	ctx := context.Background()
-	types := []DBType{DBTypeCountry, DBTypeCity, DBTypeASN}
+func (c *Client) downloadAllDB(ctx context.Context) error {
+	slog.InfoContext(ctx, "Downloading all DB files")
 
 	var hasError bool
-	for _, t := range types {
-		if err := c.downloadDB(ctx, t); err != nil {
+	var updated []DBType
+	previous := make(map[DBType]uint)
+	for _, t := range c.editions() {
+		previous[t] = c.currentVersion(t)
+		status := UpdateStatus{AttemptedAt: time.Now().UTC()}
+		if previous, err := c.readUpdateStatus(t); err == nil {
+			status.CacheValidators = previous.CacheValidators
+		}
+
+		validators, changed, err := c.downloadDB(ctx, t, status.CacheValidators)
+		switch {
+		case err != nil:
 			slog.Error("Error downloading DB", "type", t, "error", err)
+			status.Outcome = UpdateOutcomeFailed
+			status.Error = err.Error()
 			hasError = true
+		case !changed:
+			slog.Info("DB unchanged since last download", "type", t)
+			status.Outcome = UpdateOutcomeUnchanged
+		default:
+			slog.Info("DB updated", "type", t)
+			status.Outcome = UpdateOutcomeUpdated
+			status.CacheValidators = validators
+			updated = append(updated, t)
+		}
+		c.writeUpdateStatus(t, status)
+	}
+
+	// The versions to compare are opened before older versions are pruned, so the previous
+	// version is compared even when it is not retained.
+	var diffs []*pendingDiff
+	for _, t := range updated {
+		if c.config.DiffOnUpdate {
+			if d := c.openDiff(t, previous[t], c.currentVersion(t)); d != nil {
+				diffs = append(diffs, d)
+			}
 		}
+		c.pruneVersions(t)
 	}
 
 	if hasError {
 		if !c.checkAllDBFilesExist() {
+			closeDiffs(diffs)
 			return ErrDBDownloadFailed
 		}
 		slog.Warn("Continuing with existing DB files despite download errors")
 	}
 
+	if len(updated) == 0 && !c.dbFilesChanged() {
+		slog.Info("No DB changes, skipping reload")
+		return nil
+	}
+
 	if err := c.Load(); err != nil {
+		closeDiffs(diffs)
 		return fmt.Errorf("failed to reload databases: %w", err)
 	}
 
+	// The new versions are compared in the background once they are served, as a diff takes a
+	// while and must not hold up the update. Close waits for the comparisons.
+	for _, d := range diffs {
+		c.diffs.Go(func() { c.writeDiff(ctx, d) })
+	}
+
 	return nil
 }
 
-func (c *Client) downloadDB(ctx context.Context, dbType DBType) error {
+// closeDiffs closes the versions of comparisons that are not run.
+func closeDiffs(diffs []*pendingDiff) {
+	for _, d := range diffs {
+		d.close()
+	}
+}
+
+// downloadDB downloads and installs an edition. The archive is requested conditionally using the
+// validators of the previous download, and it reports false if the edition is unchanged.
+func (c *Client) downloadDB(ctx context.Context, dbType DBType, validators CacheValidators) (CacheValidators, bool, error) {
 	if len(c.config.LicenseKey) == 0 {
-		return ErrLicenseKeyRequired
+		return validators, false, ErrLicenseKeyRequired
 	}
 
 	if err := os.MkdirAll(c.dataDir, os.ModePerm); err != nil {
-		return err
+		return validators, false, err
+	}
+
+	// Without a database file there is nothing to keep, so download unconditionally.
+	if _, err := os.Stat(c.getDBPath(dbType)); err != nil {
+		validators = CacheValidators{}
 	}
 
 	tmpDir := os.TempDir()
@@ -79,8 +132,6 @@ func (c *Client) downloadDB(ctx context.Context, dbType DBType) error {
 	dbURL := fmt.Sprintf(MaxMindDownloadURL, dbType, c.config.LicenseKey, DBArchiveDownloadSuffix)
 	sha256URL := fmt.Sprintf(MaxMindDownloadURL, dbType, c.config.LicenseKey, DBSHA256FileDownloadSuffix)
 
-	finalDBPath := c.getDBPath(dbType)
-
 	// Clean up temp files on exit
 	defer func() {
 		_ = os.Remove(archivePath)
@@ -88,42 +139,145 @@ func (c *Client) downloadDB(ctx context.Context, dbType DBType) error {
 	}()
 
 	slog.Info("Downloading DB file", "path", archivePath)
-	if err := file.DownloadFile(ctx, dbURL, archivePath); err != nil {
-		return err
+	newValidators, changed, err := downloadFileIfModified(ctx, dbURL, archivePath, validators)
+	if err != nil || !changed {
+		return validators, false, err
 	}
 
 	slog.Info("Downloading sha256 file", "path", sha256Path)
 	if err := file.DownloadFile(ctx, sha256URL, sha256Path); err != nil {
-		return err
+		return validators, false, err
 	}
 
-	sha256Sum, extractName, err := c.parseSHA256File(sha256Path)
+	if err := c.installArchive(dbType, archivePath, sha256Path); err != nil {
+		return validators, false, err
+	}
+	return newValidators, true, nil
+}
+
+// downloadFileIfModified downloads url to destination unless the server reports, based on the
+// given validators, that it has not been modified. It returns the validators of the response.
+func downloadFileIfModified(ctx context.Context, url, destination string, validators CacheValidators) (CacheValidators, bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return err
+		return validators, false, err
+	}
+	if validators.ETag != "" {
+		request.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		request.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return validators, false, fmt.Errorf("failed to get url: %w", err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	switch response.StatusCode {
+	case http.StatusNotModified:
+		return validators, false, nil
+	case http.StatusOK:
+	default:
+		return validators, false, fmt.Errorf("bad status: %s", response.Status)
 	}
 
-	hasher := hash.NewSHA256Hasher()
-	valid, verifyErr := hasher.VerifyFile(archivePath, sha256Sum)
-	if verifyErr != nil {
-		return verifyErr
-	} else if !valid {
-		return fmt.Errorf("%w for archive %s", ErrChecksumMismatch, archivePath)
+	out, err := os.Create(destination)
+	if err != nil {
+		return validators, false, fmt.Errorf("failed to create destination file: %w", err)
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+
+	if _, err := io.Copy(out, response.Body); err != nil {
+		return validators, false, fmt.Errorf("failed to copy response body: %w", err)
 	}
 
-	slog.Info("Checksum validated", "path", archivePath)
+	return CacheValidators{
+		LastModified: response.Header.Get("Last-Modified"),
+		ETag:         response.Header.Get("ETag"),
+	}, true, nil
+}
+
+// installArchive verifies an edition archive against its sha256 file, if one is given,
+// extracts the database and atomically replaces the database file in the data directory.
+func (c *Client) installArchive(dbType DBType, archivePath, sha256Path string) error {
+	if sha256Path != "" {
+		sha256Sum, sha256Edition, err := c.parseSHA256File(sha256Path)
+		if err != nil {
+			return err
+		}
+		if sha256Edition != dbType {
+			return fmt.Errorf("%w: archive=%s sha256=%s", ErrEditionMismatch, dbType, sha256Edition)
+		}
+
+		hasher := hash.NewSHA256Hasher()
+		valid, verifyErr := hasher.VerifyFile(archivePath, sha256Sum)
+		if verifyErr != nil {
+			return verifyErr
+		} else if !valid {
+			return fmt.Errorf("%w for archive %s", ErrChecksumMismatch, archivePath)
+		}
 
+		slog.Info("Checksum validated", "path", archivePath)
+	}
+
+	extractName := fmt.Sprintf("%s.%s", dbType, DBSuffix)
 	slog.Info("Extracting file", "file", extractName, "archive", archivePath)
 	extractedPath, err := file.ExtractFileFromTarGz(archivePath, extractName)
 	if err != nil {
 		return err
 	}
+	defer func() { _ = os.Remove(extractedPath) }()
 
-	tmpPath := finalDBPath + ".tmp"
-	if err := copyFile(extractedPath, tmpPath); err != nil {
+	if err := c.validateCandidate(dbType, extractedPath); err != nil {
+		return err
+	}
+
+	version, err := c.storeVersion(dbType, extractedPath)
+	if err != nil {
+		return err
+	}
+	if err := c.activateVersion(dbType, version); err != nil {
 		return err
 	}
-	_ = os.Remove(extractedPath)
-	return os.Rename(tmpPath, finalDBPath)
+
+	return nil
+}
+
+// writeUpdateStatus records the outcome of an update attempt next to the database file, so it
+// survives restarts and can be read by other processes sharing the data directory.
+func (c *Client) writeUpdateStatus(t DBType, status UpdateStatus) {
+	b, err := json.Marshal(status)
+	if err != nil {
+		slog.Error("Error encoding DB update status", "type", t, "error", err)
+		return
+	}
+	if err := os.WriteFile(c.getUpdateStatusPath(t), b, 0o600); err != nil {
+		slog.Error("Error writing DB update status", "type", t, "error", err)
+	}
+}
+
+// readUpdateStatus returns the outcome of the last update attempt of a database.
+func (c *Client) readUpdateStatus(t DBType) (*UpdateStatus, error) {
+	b, err := os.ReadFile(c.getUpdateStatusPath(t))
+	if err != nil {
+		return nil, err
+	}
+
+	var status UpdateStatus
+	if err := json.Unmarshal(b, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (c *Client) getUpdateStatusPath(t DBType) string {
+	return filepath.Join(c.dataDir, fmt.Sprintf("%s.%s", t, DBUpdateStatusSuffix))
 }
 
 func copyFile(src, dst string) error {
@@ -143,7 +297,8 @@ func copyFile(src, dst string) error {
 	return err
 }
 
-func (c *Client) parseSHA256File(path string) (string, string, error) {
+// parseSHA256File returns the checksum and the edition of the archive listed in a sha256 file.
+func (c *Client) parseSHA256File(path string) (string, DBType, error) {
 	lines, err := file.ReadFileLines(path)
 	if err != nil {
 		return "", "", err
@@ -157,18 +312,16 @@ func (c *Client) parseSHA256File(path string) (string, string, error) {
 		return "", "", ErrInvalidSHA256File
 	}
 
-	sha256Sum := parts[0]
-	fileName := parts[1]
-
-	baseName := strings.Split(strings.Split(fileName, ".")[0], "_")[0]
-	dbFilename := fmt.Sprintf("%s.%s", baseName, DBSuffix)
+	return parts[0], editionFromArchiveName(parts[1]), nil
+}
 
-	return sha256Sum, dbFilename, nil
+// editionFromArchiveName returns the edition of an archive named like GeoLite2-City_20240101.tar.gz.
+func editionFromArchiveName(name string) DBType {
+	return DBType(strings.Split(strings.Split(filepath.Base(name), ".")[0], "_")[0])
 }
 
 func (c *Client) checkAllDBFilesExist() bool {
-	types := []DBType{DBTypeCountry, DBTypeCity, DBTypeASN}
-	for _, t := range types {
+	for _, t := range c.editions() {
 		if _, err := os.Stat(c.getDBPath(t)); os.IsNotExist(err) {
 			return false
 		}
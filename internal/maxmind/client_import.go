@@ -0,0 +1,142 @@
+package maxmind
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// incomingMinAge is how long an archive in the incoming directory must be left untouched
+// before it is imported, so archives that are still being copied are not picked up.
+const incomingMinAge = 5 * time.Second
+
+// ImportArchive installs a database from a local edition archive, such as one downloaded
+// from the MaxMind website on another machine. The archive is verified against the sha256
+// file if one is given. Running servers sharing the data directory pick up the new file
+// through the import watch job.
+func (c *Client) ImportArchive(archivePath, sha256Path string) (DBType, error) {
+	dbType := editionFromArchiveName(archivePath)
+	if !slices.Contains(c.editions(), dbType) {
+		return dbType, fmt.Errorf("%w: %s", ErrEditionNotConfigured, dbType)
+	}
+
+	if err := os.MkdirAll(c.dataDir, os.ModePerm); err != nil {
+		return dbType, err
+	}
+
+	slog.Info("Importing DB archive", "type", dbType, "archive", archivePath)
+	// The validators of the last download no longer describe the installed file, so they are dropped.
+	status := UpdateStatus{
+		AttemptedAt: time.Now().UTC(),
+		Outcome:     UpdateOutcomeUpdated,
+	}
+	err := c.installArchive(dbType, archivePath, sha256Path)
+	if err != nil {
+		status.Outcome = UpdateOutcomeFailed
+		status.Error = err.Error()
+		if previous, readErr := c.readUpdateStatus(dbType); readErr == nil {
+			status.CacheValidators = previous.CacheValidators
+		}
+	} else {
+		c.pruneVersions(dbType)
+	}
+	c.writeUpdateStatus(dbType, status)
+	return dbType, err
+}
+
+// RunImportWatchJob periodically imports archives dropped into the incoming directory and
+// reloads databases whose files changed on disk until the context is done.
+func RunImportWatchJob(ctx context.Context, c *Client, interval time.Duration) {
+	if err := os.MkdirAll(c.getIncomingDir(), os.ModePerm); err != nil {
+		slog.ErrorContext(ctx, "Failed to create incoming directory", "path", c.getIncomingDir(), "error", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	slog.InfoContext(ctx, "Watching incoming directory", "path", c.getIncomingDir(), "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Stopping incoming directory watch")
+			return
+		case <-ticker.C:
+			c.importIncoming(ctx)
+			if c.dbFilesChanged() {
+				if err := c.Load(); err != nil {
+					slog.ErrorContext(ctx, "Failed to reload databases", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// importIncoming imports the archives in the incoming directory. Imported archives are
+// removed, archives that fail to import are renamed with a .failed suffix.
+func (c *Client) importIncoming(ctx context.Context) {
+	entries, err := os.ReadDir(c.getIncomingDir())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.ErrorContext(ctx, "Failed to read incoming directory", "error", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		suffix := "." + DBArchiveDownloadSuffix
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), suffix) {
+			continue
+		}
+		if info, err := entry.Info(); err != nil || time.Since(info.ModTime()) < incomingMinAge {
+			continue
+		}
+
+		archivePath := filepath.Join(c.getIncomingDir(), entry.Name())
+		sha256Path := filepath.Join(c.getIncomingDir(), strings.TrimSuffix(entry.Name(), suffix)+"."+DBSHA256FileDownloadSuffix)
+		if _, err := os.Stat(sha256Path); err != nil {
+			sha256Path = ""
+		}
+
+		if dbType, err := c.ImportArchive(archivePath, sha256Path); err != nil {
+			slog.ErrorContext(ctx, "Failed to import DB archive", "type", dbType, "archive", archivePath, "error", err)
+			_ = os.Rename(archivePath, archivePath+"."+FailedImportSuffix)
+			if sha256Path != "" {
+				_ = os.Rename(sha256Path, sha256Path+"."+FailedImportSuffix)
+			}
+			continue
+		}
+
+		_ = os.Remove(archivePath)
+		if sha256Path != "" {
+			_ = os.Remove(sha256Path)
+		}
+	}
+}
+
+// dbFilesChanged reports whether a database file was replaced since it was loaded, or a
+// configured edition that is not loaded yet appeared.
+func (c *Client) dbFilesChanged() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, t := range c.editions() {
+		info, err := os.Stat(c.getDBPath(t))
+		if err != nil {
+			continue
+		}
+		loadedAt, ok := c.loadedAt[t]
+		if !ok || info.ModTime().After(loadedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) getIncomingDir() string {
+	return filepath.Join(c.dataDir, IncomingDirName)
+}
@@ -0,0 +1,81 @@
+package maxmind_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ImportArchive(t *testing.T) {
+	dataDir := t.TempDir()
+	client := maxmind.NewClient(&config.MaxMindConfig{}, dataDir)
+	defer client.Close()
+
+	dbType, err := client.ImportArchive(
+		testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz"),
+		testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz.sha256"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, maxmind.DBTypeCity, dbType)
+	assert.FileExists(t, filepath.Join(dataDir, "GeoLite2-City.mmdb"))
+
+	require.NoError(t, client.Load())
+	city, err := client.IP2City("81.2.69.142")
+	require.NoError(t, err)
+	assert.Equal(t, "London", city.City)
+
+	metadata := client.Metadata()
+	require.Len(t, metadata, 1)
+	require.NotNil(t, metadata[0].LastUpdate)
+	assert.Equal(t, maxmind.UpdateOutcomeUpdated, metadata[0].LastUpdate.Outcome)
+
+	// Archives can be imported without a checksum
+	_, err = client.ImportArchive(testhelpers.TestDataPath(t, "GeoLite2-ASN.tar.gz"), "")
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dataDir, "GeoLite2-ASN.mmdb"))
+
+	_, err = client.ImportArchive(
+		testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz"),
+		testhelpers.TestDataPath(t, "GeoLite2-ASN.tar.gz.sha256"),
+	)
+	require.ErrorIs(t, err, maxmind.ErrEditionMismatch)
+
+	client = maxmind.NewClient(&config.MaxMindConfig{Editions: []string{"GeoLite2-ASN"}}, dataDir)
+	_, err = client.ImportArchive(testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz"), "")
+	require.ErrorIs(t, err, maxmind.ErrEditionNotConfigured)
+}
+
+func TestRunImportWatchJob(t *testing.T) {
+	dataDir := t.TempDir()
+	incomingDir := filepath.Join(dataDir, maxmind.IncomingDirName)
+	require.NoError(t, os.MkdirAll(incomingDir, os.ModePerm))
+
+	// Drop an archive that has been fully copied a while ago
+	archivePath := filepath.Join(incomingDir, "GeoLite2-City.tar.gz")
+	b, err := os.ReadFile(testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(archivePath, b, 0o600))
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, os.Chtimes(archivePath, past, past))
+
+	client := maxmind.NewClient(&config.MaxMindConfig{}, dataDir)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go maxmind.RunImportWatchJob(ctx, client, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		_, err := client.IP2City("81.2.69.142")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoFileExists(t, archivePath)
+}
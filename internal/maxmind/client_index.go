@@ -0,0 +1,311 @@
+package maxmind
+
+import (
+	"log/slog"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// reverseIndex maps ASNs and countries to their networks in the loaded databases.
+type reverseIndex struct {
+	asns      map[uint]*asnEntry
+	countries map[string][]netip.Prefix
+	builtAt   time.Time
+}
+
+type asnEntry struct {
+	organization string
+	networks     []netip.Prefix
+}
+
+// countryRecord decodes only the country of a Country or City record.
+type countryRecord struct {
+	Country struct {
+		IsoCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// ReverseIndexStats describes the reverse index.
+type ReverseIndexStats struct {
+	BuiltAt   time.Time `json:"built_at"`
+	ASNs      int       `json:"asns"`
+	Countries int       `json:"countries"`
+}
+
+// ASNNetworks describes the networks of an autonomous system.
+type ASNNetworks struct {
+	ASN           uint     `json:"asn"`
+	Organization  string   `json:"organization"`
+	Networks      []string `json:"networks"`
+	IPv4Networks  int      `json:"ipv4_networks"`
+	IPv6Networks  int      `json:"ipv6_networks"`
+	IPv4Addresses uint64   `json:"ipv4_addresses"`
+	// Countries are the countries the networks are located in.
+	Countries []string `json:"countries"`
+}
+
+// CountryNetworks is a page of the networks located in a country.
+type CountryNetworks struct {
+	ISOCountryCode string   `json:"iso_country_code"`
+	Networks       []string `json:"networks"`
+	IPv4Networks   int      `json:"ipv4_networks"`
+	IPv6Networks   int      `json:"ipv6_networks"`
+}
+
+// EnableReverseIndex makes Load index the networks of the ASN and Country editions, for
+// ASNNetworks and CountryNetworks.
+func (c *Client) EnableReverseIndex() {
+	c.indexEnabled.Store(true)
+}
+
+// ReverseIndexStats returns the description of the reverse index. It reports false if the
+// index is not built.
+func (c *Client) ReverseIndexStats() (ReverseIndexStats, bool) {
+	idx := c.index.Load()
+	if idx == nil {
+		return ReverseIndexStats{}, false
+	}
+	return ReverseIndexStats{BuiltAt: idx.builtAt, ASNs: len(idx.asns), Countries: len(idx.countries)}, true
+}
+
+// ASNNetworks returns the networks of an autonomous system and the countries they are located in.
+func (c *Client) ASNNetworks(asn uint) (ASNNetworks, error) {
+	idx := c.index.Load()
+	if idx == nil {
+		return ASNNetworks{}, ErrReverseIndexUnavailable
+	}
+	entry, ok := idx.asns[asn]
+	if !ok {
+		return ASNNetworks{}, ErrASNNotFound
+	}
+
+	result := ASNNetworks{
+		ASN:          asn,
+		Organization: entry.organization,
+		Networks:     make([]string, 0, len(entry.networks)),
+	}
+	for _, p := range entry.networks {
+		result.Networks = append(result.Networks, p.String())
+		if p.Addr().Is4() {
+			result.IPv4Networks++
+			result.IPv4Addresses += 1 << (32 - p.Bits())
+		} else {
+			result.IPv6Networks++
+		}
+	}
+
+	countries, err := c.countriesWithin(entry.networks)
+	if err != nil {
+		return ASNNetworks{}, err
+	}
+	result.Countries = countries
+	return result, nil
+}
+
+// CountryNetworks returns the networks located in a country, in address order, starting at the
+// network containing or following cursor. It returns at most limit networks and the cursor of
+// the next page, or nil if there are no more networks.
+func (c *Client) CountryNetworks(isoCode string, cursor net.IP, limit int) (CountryNetworks, net.IP, error) {
+	idx := c.index.Load()
+	if idx == nil {
+		return CountryNetworks{}, nil, ErrReverseIndexUnavailable
+	}
+	isoCode = strings.ToUpper(isoCode)
+	networks, ok := idx.countries[isoCode]
+	if !ok {
+		return CountryNetworks{}, nil, ErrCountryNotFound
+	}
+
+	result := CountryNetworks{ISOCountryCode: isoCode}
+	for _, p := range networks {
+		if p.Addr().Is4() {
+			result.IPv4Networks++
+		} else {
+			result.IPv6Networks++
+		}
+	}
+
+	start := 0
+	if cursor != nil {
+		addr, ok := netip.AddrFromSlice(cursor)
+		if !ok {
+			return CountryNetworks{}, nil, ErrInvalidCursor
+		}
+		addr = addr.Unmap()
+		// The first network that does not end before the cursor.
+		start, _ = slices.BinarySearchFunc(networks, addr, func(p netip.Prefix, a netip.Addr) int {
+			if p.Contains(a) {
+				return 0
+			}
+			return p.Addr().Compare(a)
+		})
+	}
+
+	end := min(start+limit, len(networks))
+	result.Networks = make([]string, 0, end-start)
+	for _, p := range networks[start:end] {
+		result.Networks = append(result.Networks, p.String())
+	}
+
+	var next net.IP
+	if end < len(networks) {
+		next = net.IP(networks[end].Addr().AsSlice())
+	}
+	return result, next, nil
+}
+
+// rebuildIndex rebuilds the reverse index from the loaded databases and swaps it in, if enabled.
+// Lookups keep using the previous index until the new one is complete.
+func (c *Client) rebuildIndex() {
+	if !c.indexEnabled.Load() {
+		return
+	}
+
+	c.indexMu.Lock()
+	defer c.indexMu.Unlock()
+
+	start := time.Now()
+	idx, err := c.buildIndex()
+	if err != nil {
+		slog.Error("Failed to build reverse index, keeping the previous one", "error", err)
+		return
+	}
+	c.index.Store(idx)
+	slog.Info("Built reverse index", "asns", len(idx.asns), "countries", len(idx.countries), "duration", time.Since(start))
+}
+
+// buildIndex indexes the networks of the first loaded ASN and Country (or City) editions. The
+// readers return networks in address order, so the indexed networks are sorted. The caller must
+// hold indexMu, which keeps the readers open while they are walked without holding mu.
+func (c *Client) buildIndex() (*reverseIndex, error) {
+	c.mu.RLock()
+	asnReader := c.loadedReader(asnDBTypes)
+	countryReader := c.countryReader()
+	c.mu.RUnlock()
+
+	idx := &reverseIndex{
+		asns:      make(map[uint]*asnEntry),
+		countries: make(map[string][]netip.Prefix),
+		builtAt:   time.Now().UTC(),
+	}
+
+	if asnReader != nil {
+		networks := asnReader.Networks(maxminddb.SkipAliasedNetworks)
+		for networks.Next() {
+			var record geoip2.ASN
+			network, err := networks.Network(&record)
+			if err != nil {
+				return nil, err
+			}
+			if record.AutonomousSystemNumber == 0 {
+				continue
+			}
+
+			entry, ok := idx.asns[record.AutonomousSystemNumber]
+			if !ok {
+				entry = &asnEntry{}
+				idx.asns[record.AutonomousSystemNumber] = entry
+			}
+			// Not every network of an ASN carries its organization.
+			if entry.organization == "" {
+				entry.organization = record.AutonomousSystemOrganization
+			}
+			entry.networks = append(entry.networks, toPrefix(network))
+		}
+		if err := networks.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if countryReader != nil {
+		networks := countryReader.Networks(maxminddb.SkipAliasedNetworks)
+		for networks.Next() {
+			var record countryRecord
+			network, err := networks.Network(&record)
+			if err != nil {
+				return nil, err
+			}
+			if record.Country.IsoCode != "" {
+				idx.countries[record.Country.IsoCode] = append(idx.countries[record.Country.IsoCode], toPrefix(network))
+			}
+		}
+		if err := networks.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return idx, nil
+}
+
+// countriesWithin returns the countries of the networks of the first loaded Country (or City)
+// edition within the given networks.
+func (c *Client) countriesWithin(prefixes []netip.Prefix) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	reader := c.countryReader()
+	countries := []string{}
+	if reader == nil {
+		return countries, nil
+	}
+
+	for _, p := range prefixes {
+		networks := reader.NetworksWithin(toIPNet(p), maxminddb.SkipAliasedNetworks)
+		for networks.Next() {
+			var record countryRecord
+			if _, err := networks.Network(&record); err != nil {
+				return nil, err
+			}
+			if code := record.Country.IsoCode; code != "" && !slices.Contains(countries, code) {
+				countries = append(countries, code)
+			}
+		}
+		if err := networks.Err(); err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(countries)
+	return countries, nil
+}
+
+// loadedReader returns the reader of the first loaded edition of types, or nil if none is
+// loaded. The caller must hold the lock.
+func (c *Client) loadedReader(types []DBType) *maxminddb.Reader {
+	for _, t := range types {
+		if reader, ok := c.readers[t]; ok && reader != nil {
+			return reader
+		}
+	}
+	return nil
+}
+
+// countryReader returns the reader of the first loaded Country edition, falling back to the
+// City editions, or nil if none is loaded. The caller must hold the lock.
+func (c *Client) countryReader() *maxminddb.Reader {
+	if reader := c.loadedReader(countryDBTypes); reader != nil {
+		return reader
+	}
+	return c.loadedReader(cityDBTypes)
+}
+
+// toPrefix converts a network returned by a reader.
+func toPrefix(network *net.IPNet) netip.Prefix {
+	addr, _ := netip.AddrFromSlice(network.IP)
+	ones, bits := network.Mask.Size()
+	if addr.Is4In6() {
+		addr = addr.Unmap()
+		ones -= bits - 32
+	}
+	return netip.PrefixFrom(addr, ones)
+}
+
+// toIPNet converts a prefix to the form taken by the readers.
+func toIPNet(p netip.Prefix) *net.IPNet {
+	return &net.IPNet{IP: p.Addr().AsSlice(), Mask: net.CIDRMask(p.Bits(), p.Addr().BitLen())}
+}
@@ -0,0 +1,83 @@
+package maxmind_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ReverseIndex(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	defer client.Close()
+
+	// Without the index enabled, Load does not build it
+	require.NoError(t, client.Load())
+	_, err := client.ASNNetworks(209)
+	require.ErrorIs(t, err, maxmind.ErrReverseIndexUnavailable)
+
+	client.EnableReverseIndex()
+	require.NoError(t, client.Load())
+	stats, ok := client.ReverseIndexStats()
+	require.True(t, ok)
+	assert.Positive(t, stats.ASNs)
+	assert.Positive(t, stats.Countries)
+
+	asn, err := client.ASNNetworks(209)
+	require.NoError(t, err)
+	assert.Equal(t, "Qwest Communications Company, LLC", asn.Organization)
+	assert.Contains(t, asn.Networks, "216.160.64.0/18")
+	assert.Contains(t, asn.Networks, "2602::/24")
+	assert.Equal(t, 17, asn.IPv4Networks)
+	assert.Equal(t, 1, asn.IPv6Networks)
+	assert.Equal(t, []string{"US"}, asn.Countries)
+
+	_, err = client.ASNNetworks(4294967295)
+	require.ErrorIs(t, err, maxmind.ErrASNNotFound)
+
+	// Pages continue at the cursor
+	country, next, err := client.CountryNetworks("se", nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "SE", country.ISOCountryCode)
+	assert.Equal(t, []string{"89.160.20.112/28", "89.160.20.128/25"}, country.Networks)
+	assert.Equal(t, 2, country.IPv4Networks)
+	assert.Equal(t, "2001:220::", next.String())
+
+	country, _, err = client.CountryNetworks("SE", next, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2001:220::/128"}, country.Networks)
+
+	_, _, err = client.CountryNetworks("ZZ", nil, 10)
+	require.ErrorIs(t, err, maxmind.ErrCountryNotFound)
+
+	client.Close()
+	_, ok = client.ReverseIndexStats()
+	assert.False(t, ok)
+}
+
+func TestClient_ReverseIndex_ConcurrentLoads(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	defer client.Close()
+	client.EnableReverseIndex()
+	require.NoError(t, client.Load())
+
+	// Reloads replace the readers while other builds walk them and lookups are served
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() { assert.NoError(t, client.Load()) })
+		wg.Go(func() {
+			geoIP, err := client.IP2Geo("216.160.83.56")
+			assert.NoError(t, err)
+			assert.Equal(t, "Milton", geoIP.City)
+		})
+	}
+	wg.Wait()
+
+	asn, err := client.ASNNetworks(209)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"US"}, asn.Countries)
+}
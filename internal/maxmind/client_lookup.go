@@ -1,101 +1,173 @@
 package maxmind
 
 import (
+	"errors"
+	"maps"
 	"net"
+	"slices"
 
 	"github.com/oschwald/geoip2-golang"
 )
 
-// Country looks up country information for an IP.
-func (c *Client) Country(ip net.IP) (*geoip2.Country, error) {
+// lookup decodes the record for an IP from the first loaded edition of types and returns
+// the edition and network it matched, and whether the edition has a record for the IP.
+func (c *Client) lookup(types []DBType, ip net.IP, record any, notLoadedErr error) (DBType, *net.IPNet, bool, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	reader, ok := c.readers[DBTypeCountry]
 
-	if !ok || reader == nil {
-		return nil, ErrCountryDBNotLoaded
+	for _, t := range types {
+		if reader, ok := c.readers[t]; ok && reader != nil {
+			network, found, err := reader.LookupNetwork(ip, record)
+			return t, network, found, err
+		}
 	}
-	return reader.Country(ip)
+	return "", nil, false, notLoadedErr
 }
 
-// City looks up city information for an IP.
-func (c *Client) City(ip net.IP) (*geoip2.City, error) {
+// loadedType returns the first loaded edition of types, or an empty DBType if none is loaded.
+func (c *Client) loadedType(types []DBType) DBType {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	reader, ok := c.readers[DBTypeCity]
 
-	if !ok || reader == nil {
-		return nil, ErrCityDBNotLoaded
+	for _, t := range types {
+		if reader, ok := c.readers[t]; ok && reader != nil {
+			return t
+		}
 	}
-	return reader.City(ip)
+	return ""
 }
 
-// ASN looks up ASN information for an IP.
-func (c *Client) ASN(ip net.IP) (*geoip2.ASN, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	reader, ok := c.readers[DBTypeASN]
+// lookupEdition decodes the record for an IP from a single edition and records the matched
+// network. It reports false if the edition is not loaded.
+func (c *Client) lookupEdition(t DBType, ip net.IP, record any, networks map[DBType]string) (bool, error) {
+	if c.loadedType([]DBType{t}) == "" {
+		return false, nil
+	}
+
+	_, network, _, err := c.lookup([]DBType{t}, ip, record, ErrDBNotLoaded)
+	if err != nil {
+		return false, err
+	}
+	networks[t] = network.String()
+	return true, nil
+}
+
+// Country looks up country information for an IP and returns the network it matched.
+func (c *Client) Country(ip net.IP) (*geoip2.Country, *net.IPNet, error) {
+	var record geoip2.Country
+	_, network, _, err := c.lookup(countryDBTypes, ip, &record, ErrCountryDBNotLoaded)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &record, network, nil
+}
 
-	if !ok || reader == nil {
-		return nil, ErrASNDBNotLoaded
+// City looks up city information for an IP and returns the network it matched.
+func (c *Client) City(ip net.IP) (*geoip2.City, *net.IPNet, error) {
+	var record geoip2.City
+	_, network, _, err := c.lookup(cityDBTypes, ip, &record, ErrCityDBNotLoaded)
+	if err != nil {
+		return nil, nil, err
 	}
-	return reader.ASN(ip)
+	return &record, network, nil
+}
+
+// ASN looks up ASN information for an IP and returns the network it matched.
+func (c *Client) ASN(ip net.IP) (*geoip2.ASN, *net.IPNet, error) {
+	var record geoip2.ASN
+	_, network, _, err := c.lookup(asnDBTypes, ip, &record, ErrASNDBNotLoaded)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &record, network, nil
 }
 
 // IP2Country looks up country information for an IP address.
-func (c *Client) IP2Country(ipStr string) (IPCountry, error) {
+func (c *Client) IP2Country(ipStr string, opts ...LookupOption) (IPCountry, error) {
 	ipCountry := IPCountry{}
+	o := newLookupOptions(opts...)
 
 	parsedIP := net.ParseIP(ipStr)
 	if parsedIP == nil {
 		return ipCountry, ErrInvalidIP
 	}
 
-	record, err := c.Country(parsedIP)
+	record, network, err := c.Country(parsedIP)
 	if err != nil {
 		return ipCountry, err
 	}
 
+	ipCountry = newIPCountry(record, o.language)
 	ipCountry.IP = ipStr
-	ipCountry.Continent = record.Continent.Names["en"]
-	ipCountry.Country = record.Country.Names["en"]
-	ipCountry.ISOContinentCode = record.Continent.Code
-	ipCountry.ISOCountryCode = record.Country.IsoCode
-	ipCountry.IsAnonymousProxy = record.Traits.IsAnonymousProxy
-	ipCountry.IsSatelliteProvider = record.Traits.IsSatelliteProvider
+	ipCountry.Network = network.String()
 
 	return ipCountry, nil
 }
 
+// newIPCountry maps a Country record, with names in the given language.
+func newIPCountry(record *geoip2.Country, lang string) IPCountry {
+	return IPCountry{
+		Continent:           localizedName(record.Continent.Names, lang),
+		Country:             localizedName(record.Country.Names, lang),
+		ISOContinentCode:    record.Continent.Code,
+		ISOCountryCode:      record.Country.IsoCode,
+		IsAnonymousProxy:    record.Traits.IsAnonymousProxy,
+		IsSatelliteProvider: record.Traits.IsSatelliteProvider,
+	}
+}
+
 // IP2City looks up city information for an IP address.
-func (c *Client) IP2City(ipStr string) (IPCity, error) {
+func (c *Client) IP2City(ipStr string, opts ...LookupOption) (IPCity, error) {
 	ipCity := IPCity{}
+	o := newLookupOptions(opts...)
 
 	parsedIP := net.ParseIP(ipStr)
 	if parsedIP == nil {
 		return ipCity, ErrInvalidIP
 	}
 
-	record, err := c.City(parsedIP)
+	record, network, err := c.City(parsedIP)
 	if err != nil {
 		return ipCity, err
 	}
 
+	ipCity = newIPCity(record, o.language)
 	ipCity.IP = ipStr
-	ipCity.City = record.City.Names["en"]
-	ipCity.Timezone = record.Location.TimeZone
-	ipCity.Latitude = record.Location.Latitude
-	ipCity.Longitude = record.Location.Longitude
-	ipCity.Country = record.Country.Names["en"]
-	ipCity.Continent = record.Continent.Names["en"]
-	ipCity.ISOCountryCode = record.Country.IsoCode
-	ipCity.ISOContinentCode = record.Continent.Code
-	ipCity.IsAnonymousProxy = record.Traits.IsAnonymousProxy
-	ipCity.IsSatelliteProvider = record.Traits.IsSatelliteProvider
+	ipCity.Network = network.String()
 
 	return ipCity, nil
 }
 
+// newIPCity maps a City record, with names in the given language.
+func newIPCity(record *geoip2.City, lang string) IPCity {
+	ipCity := IPCity{
+		City:           localizedName(record.City.Names, lang),
+		Timezone:       record.Location.TimeZone,
+		Latitude:       record.Location.Latitude,
+		Longitude:      record.Location.Longitude,
+		AccuracyRadius: record.Location.AccuracyRadius,
+		MetroCode:      record.Location.MetroCode,
+		PostalCode:     record.Postal.Code,
+		IPCountry: IPCountry{
+			Country:             localizedName(record.Country.Names, lang),
+			Continent:           localizedName(record.Continent.Names, lang),
+			ISOCountryCode:      record.Country.IsoCode,
+			ISOContinentCode:    record.Continent.Code,
+			IsAnonymousProxy:    record.Traits.IsAnonymousProxy,
+			IsSatelliteProvider: record.Traits.IsSatelliteProvider,
+		},
+	}
+
+	ipCity.Subdivisions = make([]Subdivision, 0, len(record.Subdivisions))
+	for _, s := range record.Subdivisions {
+		ipCity.Subdivisions = append(ipCity.Subdivisions, Subdivision{
+			ISOCode: s.IsoCode,
+			Name:    localizedName(s.Names, lang),
+		})
+	}
+	return ipCity
+}
+
 // IP2ASN looks up ASN information for an IP address.
 func (c *Client) IP2ASN(ipStr string) (IPASN, error) {
 	ipAsn := IPASN{}
@@ -105,36 +177,265 @@ func (c *Client) IP2ASN(ipStr string) (IPASN, error) {
 		return ipAsn, ErrInvalidIP
 	}
 
-	record, err := c.ASN(parsedIP)
+	record, network, err := c.ASN(parsedIP)
 	if err != nil {
 		return ipAsn, err
 	}
 
 	ipAsn.IP = ipStr
+	ipAsn.Network = network.String()
 	ipAsn.ASN = record.AutonomousSystemNumber
 	ipAsn.Organization = record.AutonomousSystemOrganization
 
 	return ipAsn, nil
 }
 
-// IP2Geo looks up all geographic information for an IP address.
-func (c *Client) IP2Geo(ipStr string) (GeoIP, error) {
-	geoIP := GeoIP{
-		IP: ipStr,
+// IP2Geo looks up all geographic information for an IP address, including the network
+// traits of any loaded GeoIP2 commercial editions. Results are served from the lookup cache
+// when it is enabled.
+func (c *Client) IP2Geo(ipStr string, opts ...LookupOption) (GeoIP, error) {
+	if c.cache == nil {
+		return c.ip2Geo(ipStr, opts...)
 	}
 
-	ipCity, err := c.IP2City(ipStr)
-	if err != nil {
-		return geoIP, err
+	key := newLookupOptions(opts...).cacheKey(ipStr)
+	geoIP, generation, ok := c.cache.get(key)
+	if ok {
+		return geoIP, nil
 	}
 
-	ipAsn, err := c.IP2ASN(ipStr)
+	geoIP, err := c.ip2Geo(ipStr, opts...)
 	if err != nil {
 		return geoIP, err
 	}
+	c.cache.put(key, generation, geoIP)
+	return geoIP, nil
+}
+
+// ip2Geo looks up all geographic information for an IP address in the loaded databases.
+// Databases answering none of the selected fields are skipped. Missing or failing databases
+// are reported as warnings on the result, and only fail the lookup if no database answered.
+func (c *Client) ip2Geo(ipStr string, opts ...LookupOption) (GeoIP, error) {
+	geoIP := GeoIP{
+		IP:       ipStr,
+		Networks: make(map[DBType]string),
+	}
+	o := newLookupOptions(opts...)
+
+	parsedIP := net.ParseIP(ipStr)
+	if parsedIP == nil {
+		return geoIP, ErrInvalidIP
+	}
 
-	geoIP.IPCity = ipCity
-	geoIP.IPASN = ipAsn
+	if o.specialPurpose {
+		// Special-purpose addresses are only described by the custom editions.
+		if !c.setCustom(parsedIP, &geoIP, o) {
+			if len(geoIP.Warnings) > 0 {
+				return geoIP, noDataError(geoIP.Warnings)
+			}
+			return geoIP, ErrNoDataAvailable
+		}
+		geoIP.Network = narrowestNetwork(slices.Collect(maps.Values(geoIP.Networks))...)
+		return geoIP, nil
+	}
+
+	c.setLocation(ipStr, &geoIP, o, opts...)
+
+	if o.wants(asnFields...) {
+		ipAsn, err := c.IP2ASN(ipStr)
+		switch {
+		case err == nil:
+			geoIP.IPASN = ipAsn
+			geoIP.Networks[c.loadedType(asnDBTypes)] = ipAsn.Network
+		case errors.Is(err, ErrASNDBNotLoaded) && c.loadedType([]DBType{DBTypeEnterprise}) != "":
+			// The Enterprise edition carries the ASN in its traits, set below.
+		default:
+			geoIP.warn(c.source(asnDBTypes, "asn"), err)
+		}
+	}
+
+	c.setTraits(parsedIP, &geoIP, o)
+	c.setCustom(parsedIP, &geoIP, o)
+
+	if len(geoIP.Networks) == 0 && len(geoIP.Warnings) > 0 {
+		return geoIP, noDataError(geoIP.Warnings)
+	}
+
+	geoIP.Network = narrowestNetwork(slices.Collect(maps.Values(geoIP.Networks))...)
 
 	return geoIP, nil
 }
+
+// setLocation sets the city fields, falling back to the country fields when no City edition can
+// answer. The smaller Country editions are used directly when no city fields are selected.
+func (c *Client) setLocation(ipStr string, geoIP *GeoIP, o lookupOptions, opts ...LookupOption) {
+	if o.wants(cityFields...) || (o.wants(countryFields...) && c.loadedType(countryDBTypes) == "") {
+		ipCity, err := c.IP2City(ipStr, opts...)
+		if err == nil {
+			geoIP.IPCity = ipCity
+			geoIP.Networks[c.loadedType(cityDBTypes)] = ipCity.Network
+			return
+		}
+		geoIP.warn(c.source(cityDBTypes, "city"), err)
+	}
+
+	if o.wants(countryFields...) {
+		ipCountry, err := c.IP2Country(ipStr, opts...)
+		if err != nil {
+			geoIP.warn(c.source(countryDBTypes, "country"), err)
+			return
+		}
+		geoIP.IPCountry = ipCountry
+		geoIP.Subdivisions = []Subdivision{}
+		geoIP.Networks[c.loadedType(countryDBTypes)] = ipCountry.Network
+	}
+}
+
+// source names the first loaded edition of types, or the kind of database if none is loaded.
+func (c *Client) source(types []DBType, kind string) string {
+	if t := c.loadedType(types); t != "" {
+		return string(t)
+	}
+	return kind
+}
+
+// setTraits sets the selected fields provided by the loaded GeoIP2 commercial editions.
+func (c *Client) setTraits(ip net.IP, geoIP *GeoIP, o lookupOptions) {
+	enterpriseASN := o.wants(asnFields...) && geoIP.IPASN.IP == ""
+	if o.wants(enterpriseFields...) || o.wants("isp", "connection_type", "domain") || enterpriseASN {
+		var enterprise geoip2.Enterprise
+		if ok, err := c.lookupEdition(DBTypeEnterprise, ip, &enterprise, geoIP.Networks); err != nil {
+			geoIP.warn(string(DBTypeEnterprise), err)
+		} else if ok {
+			geoIP.ISP = enterprise.Traits.ISP
+			geoIP.ConnectionType = enterprise.Traits.ConnectionType
+			geoIP.Domain = enterprise.Traits.Domain
+			geoIP.UserType = enterprise.Traits.UserType
+			geoIP.Confidence = &Confidence{
+				Country:    enterprise.Country.Confidence,
+				City:       enterprise.City.Confidence,
+				PostalCode: enterprise.Postal.Confidence,
+			}
+			if len(enterprise.Subdivisions) > 0 {
+				geoIP.Confidence.Subdivision = enterprise.Subdivisions[0].Confidence
+			}
+			if enterpriseASN {
+				geoIP.IPASN = IPASN{
+					IP:           geoIP.IP,
+					Network:      geoIP.Networks[DBTypeEnterprise],
+					ASN:          enterprise.Traits.AutonomousSystemNumber,
+					Organization: enterprise.Traits.AutonomousSystemOrganization,
+				}
+			}
+		}
+	}
+
+	if o.wants("isp") {
+		var isp geoip2.ISP
+		if ok, err := c.lookupEdition(DBTypeISP, ip, &isp, geoIP.Networks); err != nil {
+			geoIP.warn(string(DBTypeISP), err)
+		} else if ok {
+			geoIP.ISP = isp.ISP
+		}
+	}
+
+	if o.wants("connection_type") {
+		var connectionType geoip2.ConnectionType
+		if ok, err := c.lookupEdition(DBTypeConnectionType, ip, &connectionType, geoIP.Networks); err != nil {
+			geoIP.warn(string(DBTypeConnectionType), err)
+		} else if ok {
+			geoIP.ConnectionType = connectionType.ConnectionType
+		}
+	}
+
+	if o.wants(anonymousIPFields...) {
+		var anonymousIP geoip2.AnonymousIP
+		if ok, err := c.lookupEdition(DBTypeAnonymousIP, ip, &anonymousIP, geoIP.Networks); err != nil {
+			geoIP.warn(string(DBTypeAnonymousIP), err)
+		} else if ok {
+			geoIP.IsVPN = &anonymousIP.IsAnonymousVPN
+			geoIP.IsTor = &anonymousIP.IsTorExitNode
+			geoIP.IsHosting = &anonymousIP.IsHostingProvider
+		}
+	}
+
+	if o.wants("domain") {
+		var domain geoip2.Domain
+		if ok, err := c.lookupEdition(DBTypeDomain, ip, &domain, geoIP.Networks); err != nil {
+			geoIP.warn(string(DBTypeDomain), err)
+		} else if ok {
+			geoIP.Domain = domain.Domain
+		}
+	}
+}
+
+// setCustom merges the records of the loaded custom editions into the result, in the configured
+// order, so later editions override earlier ones. It reports whether any edition has a record for
+// the address.
+func (c *Client) setCustom(ip net.IP, geoIP *GeoIP, o lookupOptions) bool {
+	if !o.wants(customFields...) {
+		return false
+	}
+
+	matched := false
+	for _, t := range c.customEditions() {
+		if c.loadedType([]DBType{t}) == "" {
+			continue
+		}
+		var record customRecord
+		_, network, found, err := c.lookup([]DBType{t}, ip, &record, ErrDBNotLoaded)
+		if err != nil {
+			geoIP.warn(string(t), err)
+			continue
+		}
+		// Editions without a record for the address do not describe it.
+		if !found {
+			continue
+		}
+		geoIP.Networks[t] = network.String()
+		matched = true
+
+		if record.Country.IsoCode != "" {
+			geoIP.ISOCountryCode = record.Country.IsoCode
+		}
+		if name := localizedName(record.Country.Names, o.language); name != "" {
+			geoIP.Country = name
+		}
+		if name := localizedName(record.City.Names, o.language); name != "" {
+			geoIP.City = name
+		}
+		if record.Location.Latitude != nil && record.Location.Longitude != nil {
+			geoIP.Latitude = *record.Location.Latitude
+			geoIP.Longitude = *record.Location.Longitude
+		}
+		if record.AutonomousSystemNumber != nil {
+			geoIP.ASN = *record.AutonomousSystemNumber
+		}
+		if record.AutonomousSystemOrganization != "" {
+			geoIP.Organization = record.AutonomousSystemOrganization
+		}
+		for k, v := range record.Tags {
+			if geoIP.Tags == nil {
+				geoIP.Tags = make(map[string]string)
+			}
+			geoIP.Tags[k] = v
+		}
+	}
+	return matched
+}
+
+// narrowestNetwork returns the longest of the given prefixes. All of them contain the
+// looked up IP, so it is the block over which the combined result stays the same.
+func narrowestNetwork(networks ...string) string {
+	narrowest, bits := "", -1
+	for _, n := range networks {
+		_, ipNet, err := net.ParseCIDR(n)
+		if err != nil {
+			continue
+		}
+		if ones, _ := ipNet.Mask.Size(); ones > bits {
+			narrowest, bits = n, ones
+		}
+	}
+	return narrowest
+}
@@ -0,0 +1,174 @@
+package maxmind
+
+import (
+	"net"
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// NetworksWithin looks up the distinct networks within prefix, in address order, starting at the
+// network containing cursor, or at the start of prefix if cursor is nil. A network is distinct
+// when every loaded database has a single record across it, so it is the split of prefix the
+// lookups see. Networks no database has data for are left out. It returns at most limit results,
+// with their Network set to the distinct network, and the cursor of the next page, or nil if
+// there are no more networks. Results are looked up in the databases, bypassing the lookup cache.
+func (c *Client) NetworksWithin(prefix *net.IPNet, cursor net.IP, limit int, opts ...LookupOption) ([]GeoIP, net.IP, error) {
+	prefix = normalizeNetwork(prefix)
+	if cursor != nil {
+		if cursor = normalizeIP(cursor, len(prefix.IP)); cursor == nil || !prefix.Contains(cursor) {
+			return nil, nil, ErrInvalidCursor
+		}
+	}
+
+	// Walk one network past the page, to know where the next one starts.
+	networks, err := c.distinctNetworks(prefix, cursor, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next net.IP
+	if len(networks) > limit {
+		next = networks[limit].Addr().AsSlice()
+		networks = networks[:limit]
+	}
+
+	results := make([]GeoIP, 0, len(networks))
+	for _, network := range networks {
+		geoIP, err := c.ip2Geo(network.Addr().String(), opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		geoIP.Network = network.String()
+		results = append(results, geoIP)
+	}
+	return results, next, nil
+}
+
+// distinctNetworks returns up to limit distinct networks with data within prefix that end at or
+// after cursor. The networks of every loaded database are walked side by side, cutting prefix
+// where any database starts or ends a network.
+func (c *Client) distinctNetworks(prefix *net.IPNet, cursor net.IP, limit int) ([]netip.Prefix, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	bounds := toPrefix(prefix)
+	var from netip.Addr
+	if cursor != nil {
+		from, _ = netip.AddrFromSlice(cursor)
+	}
+
+	cursors := make([]*networkCursor, 0, len(c.readers))
+	for _, reader := range c.readers {
+		nc := &networkCursor{networks: reader.NetworksWithin(prefix, maxminddb.SkipAliasedNetworks), bounds: bounds}
+		if err := nc.next(); err != nil {
+			return nil, err
+		}
+		if !nc.done {
+			cursors = append(cursors, nc)
+		}
+	}
+
+	var networks []netip.Prefix
+	for len(cursors) > 0 && len(networks) < limit {
+		// The range starts at the first network of any database, and ends where any database
+		// starts or ends one.
+		first := cursors[0].first
+		for _, nc := range cursors[1:] {
+			if nc.first.Less(first) {
+				first = nc.first
+			}
+		}
+		last := lastAddr(bounds)
+		for _, nc := range cursors {
+			end := nc.last
+			if first.Less(nc.first) {
+				end = nc.first.Prev()
+			}
+			if end.Less(last) {
+				last = end
+			}
+		}
+
+		for _, network := range rangeToPrefixes(first, last) {
+			if from.IsValid() && lastAddr(network).Less(from) {
+				continue
+			}
+			if len(networks) == limit {
+				break
+			}
+			networks = append(networks, network)
+		}
+
+		remaining := cursors[:0]
+		for _, nc := range cursors {
+			if nc.first.Compare(last) <= 0 {
+				if err := nc.consume(last); err != nil {
+					return nil, err
+				}
+			}
+			if !nc.done {
+				remaining = append(remaining, nc)
+			}
+		}
+		cursors = remaining
+	}
+	return networks, nil
+}
+
+// networkCursor walks the networks of a database within bounds in address order, as address
+// ranges consumed from the front. Only the networks are read, not their records.
+type networkCursor struct {
+	networks    *maxminddb.Networks
+	bounds      netip.Prefix
+	first, last netip.Addr
+	done        bool
+}
+
+// next moves to the next network, clipped to the bounds.
+func (n *networkCursor) next() error {
+	if !n.networks.Next() {
+		n.done = true
+		return n.networks.Err()
+	}
+
+	var record struct{}
+	network, err := n.networks.Network(&record)
+	if err != nil {
+		return err
+	}
+	// A network enclosing the bounds is returned whole.
+	p := toPrefix(network)
+	n.first, n.last = p.Masked().Addr(), lastAddr(p)
+	if start := n.bounds.Masked().Addr(); n.first.Less(start) {
+		n.first = start
+	}
+	if end := lastAddr(n.bounds); end.Less(n.last) {
+		n.last = end
+	}
+	return nil
+}
+
+// consume drops the addresses of the current network up to last.
+func (n *networkCursor) consume(last netip.Addr) error {
+	if last == n.last {
+		return n.next()
+	}
+	n.first = last.Next()
+	return nil
+}
+
+// normalizeNetwork returns a network with its address masked, in the 4-byte form for IPv4.
+func normalizeNetwork(network *net.IPNet) *net.IPNet {
+	ones, bits := network.Mask.Size()
+	ip := normalizeIP(network.IP, bits/8)
+	return &net.IPNet{IP: ip.Mask(net.CIDRMask(ones, bits)), Mask: net.CIDRMask(ones, bits)}
+}
+
+// normalizeIP returns ip in the form of the given length, or nil if it does not fit.
+func normalizeIP(ip net.IP, length int) net.IP {
+	if length == net.IPv4len {
+		return ip.To4()
+	}
+	return ip.To16()
+}
@@ -0,0 +1,73 @@
+package maxmind_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_NetworksWithin(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{CacheSize: 100, CacheTTL: time.Hour}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	networksOf := func(results []maxmind.GeoIP) []string {
+		networks := make([]string, 0, len(results))
+		for _, r := range results {
+			networks = append(networks, r.Network)
+		}
+		return networks
+	}
+
+	// The City edition splits the block, the ASN edition covers it with a single /18
+	_, prefix, err := net.ParseCIDR("216.160.83.0/24")
+	require.NoError(t, err)
+	results, next, err := client.NetworksWithin(prefix, nil, 10)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+	assert.Equal(t, []string{
+		"216.160.83.0/27", "216.160.83.32/28", "216.160.83.48/29", "216.160.83.56/29",
+		"216.160.83.64/26", "216.160.83.128/25",
+	}, networksOf(results))
+	assert.Equal(t, "Milton", results[3].City)
+	assert.Equal(t, uint(209), results[0].ASN)
+
+	// Ranges no database has data for are left out
+	_, prefix, err = net.ParseCIDR("81.2.69.128/25")
+	require.NoError(t, err)
+	results, _, err = client.NetworksWithin(prefix, nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"81.2.69.142/31", "81.2.69.144/28", "81.2.69.160/27", "81.2.69.192/28"}, networksOf(results))
+
+	// Pages continue at the cursor
+	results, next, err = client.NetworksWithin(prefix, nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"81.2.69.142/31", "81.2.69.144/28"}, networksOf(results))
+	assert.Equal(t, "81.2.69.160", next.String())
+
+	results, next, err = client.NetworksWithin(prefix, next, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"81.2.69.160/27", "81.2.69.192/28"}, networksOf(results))
+	assert.Nil(t, next)
+
+	_, prefix, err = net.ParseCIDR("2001:218::/32")
+	require.NoError(t, err)
+	results, _, err = client.NetworksWithin(prefix, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "JP", results[0].ISOCountryCode)
+
+	_, _, err = client.NetworksWithin(prefix, net.ParseIP("81.2.69.160"), 10)
+	require.ErrorIs(t, err, maxmind.ErrInvalidCursor)
+
+	// Listing networks does not fill the lookup cache
+	stats, ok := client.CacheStats()
+	require.True(t, ok)
+	assert.Zero(t, stats.Entries)
+}
@@ -1,6 +1,8 @@
 package maxmind_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/hibare/Waypoint/internal/config"
 	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -83,3 +86,196 @@ func TestClient_InvalidIP(t *testing.T) {
 	_, err = client.IP2Geo("invalid")
 	require.Error(t, err)
 }
+
+func TestClient_LocalizedNames(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	// 81.2.69.142 is London, GB in the MaxMind test databases
+	ip := "81.2.69.142"
+
+	geo, err := client.IP2Geo(ip)
+	require.NoError(t, err)
+	assert.Equal(t, "United Kingdom", geo.Country)
+	assert.Equal(t, "Europe", geo.Continent)
+
+	geo, err = client.IP2Geo(ip, maxmind.WithLanguage("zh-CN"))
+	require.NoError(t, err)
+	assert.Equal(t, "英国", geo.Country)
+	assert.Equal(t, "欧洲", geo.Continent)
+	// The city has no zh-CN name and falls back to English
+	assert.Equal(t, "London", geo.City)
+}
+
+func TestClient_NetworkAndLocationDetails(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	// 216.160.83.56 is Milton, WA, US (AS209) in the MaxMind test databases
+	ip := "216.160.83.56"
+
+	country, err := client.IP2Country(ip)
+	require.NoError(t, err)
+	assert.Equal(t, "216.160.83.56/29", country.Network)
+
+	asn, err := client.IP2ASN(ip)
+	require.NoError(t, err)
+	assert.Equal(t, "216.160.64.0/18", asn.Network)
+
+	geo, err := client.IP2Geo(ip)
+	require.NoError(t, err)
+	assert.Equal(t, "216.160.83.56/29", geo.Network)
+	assert.Equal(t, map[maxmind.DBType]string{
+		maxmind.DBTypeCity: "216.160.83.56/29",
+		maxmind.DBTypeASN:  "216.160.64.0/18",
+	}, geo.Networks)
+	assert.Equal(t, uint16(22), geo.AccuracyRadius)
+	assert.Equal(t, "98354", geo.PostalCode)
+	assert.Equal(t, uint(819), geo.MetroCode)
+	assert.Equal(t, []maxmind.Subdivision{{ISOCode: "WA", Name: "Washington"}}, geo.Subdivisions)
+	assert.Equal(t, uint(209), geo.ASN)
+}
+
+func TestClient_Editions(t *testing.T) {
+	dataDir := testhelpers.SetupTestDBDir(t)
+
+	// Serve the city test database as the commercial GeoIP2 City edition
+	require.NoError(t, os.Rename(filepath.Join(dataDir, "GeoLite2-City.mmdb"), filepath.Join(dataDir, "GeoIP2-City.mmdb")))
+
+	client := maxmind.NewClient(&config.MaxMindConfig{
+		Editions: []string{"GeoIP2-City", "GeoLite2-ASN"},
+	}, dataDir)
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	geo, err := client.IP2Geo("216.160.83.56")
+	require.NoError(t, err)
+	assert.Equal(t, "Milton", geo.City)
+	assert.Equal(t, uint(209), geo.ASN)
+	assert.Equal(t, map[maxmind.DBType]string{
+		maxmind.DBTypeGeoIP2City: "216.160.83.56/29",
+		maxmind.DBTypeASN:        "216.160.64.0/18",
+	}, geo.Networks)
+
+	// Traits are only returned when an edition providing them is loaded
+	assert.Equal(t, maxmind.IPTraits{}, geo.IPTraits)
+
+	_, err = client.IP2Country("216.160.83.56")
+	require.ErrorIs(t, err, maxmind.ErrCountryDBNotLoaded)
+
+	// Without City and ASN editions the lookup falls back to the Country edition
+	client = maxmind.NewClient(&config.MaxMindConfig{Editions: []string{"GeoLite2-Country"}}, dataDir)
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	geo, err = client.IP2Geo("216.160.83.56")
+	require.NoError(t, err)
+	assert.Equal(t, "US", geo.ISOCountryCode)
+	assert.Empty(t, geo.City)
+	assert.Equal(t, "216.160.83.56/29", geo.Network)
+	assert.Equal(t, []maxmind.Warning{
+		{Source: "city", Error: maxmind.ErrCityDBNotLoaded.Error()},
+		{Source: "asn", Error: maxmind.ErrASNDBNotLoaded.Error()},
+	}, geo.Warnings)
+
+	// Only a lookup nothing can answer fails
+	client = maxmind.NewClient(&config.MaxMindConfig{Editions: []string{"GeoIP2-ISP"}}, dataDir)
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	_, err = client.IP2Geo("216.160.83.56")
+	require.ErrorIs(t, err, maxmind.ErrNoDataAvailable)
+
+	// The commercial GeoIP2 ISP edition is preferred over GeoLite2 ASN for ASN lookups
+	b, err := os.ReadFile(filepath.Join(dataDir, "GeoLite2-ASN.mmdb"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "GeoIP2-ISP.mmdb"), b, 0o600))
+
+	client = maxmind.NewClient(&config.MaxMindConfig{Editions: []string{"GeoLite2-ASN", "GeoIP2-ISP"}}, dataDir)
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	asn, err := client.IP2ASN("216.160.83.56")
+	require.NoError(t, err)
+	assert.Equal(t, uint(209), asn.ASN)
+	geo, err = client.IP2Geo("216.160.83.56", maxmind.WithFields("asn"))
+	require.NoError(t, err)
+	assert.Equal(t, map[maxmind.DBType]string{maxmind.DBTypeISP: "216.160.64.0/18"}, geo.Networks)
+}
+
+func TestClient_Metadata(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	defer client.Close()
+
+	// Without a license key every download fails and the existing files are loaded
+	require.NoError(t, client.DownloadAllDB())
+
+	metadata := client.Metadata()
+	require.Len(t, metadata, 3)
+
+	city := metadata[1]
+	assert.Equal(t, "GeoLite2-City", city.Name)
+	assert.Equal(t, "GeoIP2-City", city.DatabaseType)
+	assert.Equal(t, uint(6), city.IPVersion)
+	assert.Equal(t, time.Date(2022, 7, 26, 14, 53, 10, 0, time.UTC), city.BuildEpoch)
+	assert.Positive(t, city.FileSize)
+	assert.False(t, city.LoadedAt.IsZero())
+
+	require.NotNil(t, city.LastUpdate)
+	assert.Equal(t, maxmind.UpdateOutcomeFailed, city.LastUpdate.Outcome)
+	assert.Equal(t, maxmind.ErrLicenseKeyRequired.Error(), city.LastUpdate.Error)
+}
+
+func TestClient_ConditionalDownload(t *testing.T) {
+	archive, err := os.ReadFile(testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz"))
+	require.NoError(t, err)
+	sha256, err := os.ReadFile(testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz.sha256"))
+	require.NoError(t, err)
+
+	var archiveDownloads, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("suffix") == "tar.gz.sha256" {
+			_, _ = w.Write(sha256)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		archiveDownloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Tue, 14 Jan 2025 18:02:31 GMT")
+		_, _ = w.Write(archive)
+	}))
+	defer srv.Close()
+
+	downloadURL := maxmind.MaxMindDownloadURL
+	maxmind.MaxMindDownloadURL = srv.URL + maxmind.MaxMindDownloadPathQuery
+	defer func() { maxmind.MaxMindDownloadURL = downloadURL }()
+
+	client := maxmind.NewClient(&config.MaxMindConfig{
+		LicenseKey: "test-key",
+		Editions:   []string{"GeoLite2-City"},
+	}, t.TempDir())
+	defer client.Close()
+
+	require.NoError(t, client.DownloadAllDB())
+	metadata := client.Metadata()
+	require.Len(t, metadata, 1)
+	assert.Equal(t, maxmind.UpdateOutcomeUpdated, metadata[0].LastUpdate.Outcome)
+	assert.Equal(t, `"v1"`, metadata[0].LastUpdate.ETag)
+	loadedAt := metadata[0].LoadedAt
+
+	require.NoError(t, client.DownloadAllDB())
+	metadata = client.Metadata()
+	require.Len(t, metadata, 1)
+	assert.Equal(t, maxmind.UpdateOutcomeUnchanged, metadata[0].LastUpdate.Outcome)
+	assert.Equal(t, `"v1"`, metadata[0].LastUpdate.ETag)
+	assert.Equal(t, loadedAt, metadata[0].LoadedAt, "unchanged databases are not reloaded")
+
+	assert.Equal(t, 1, archiveDownloads)
+	assert.Equal(t, 1, notModified)
+}
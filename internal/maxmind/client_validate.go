@@ -0,0 +1,82 @@
+package maxmind
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// canaryRecord holds the fields of a record that canary lookups are checked against.
+type canaryRecord struct {
+	Country struct {
+		IsoCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	AutonomousSystemNumber uint `maxminddb:"autonomous_system_number"`
+	Traits                 struct {
+		AutonomousSystemNumber uint `maxminddb:"autonomous_system_number"`
+	} `maxminddb:"traits"`
+}
+
+// validateCandidate checks a new database file before it replaces the current one: it must
+// open, be of the expected edition and answer the configured canary lookups as expected.
+func (c *Client) validateCandidate(dbType DBType, path string) error {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return fmt.Errorf("%w: type=%s path=%s err=%w", ErrDBValidationFailed, dbType, path, err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	if editionProduct(reader.Metadata.DatabaseType) != editionProduct(string(dbType)) {
+		return fmt.Errorf("%w: expected %s database, got %s", ErrDBValidationFailed, dbType, reader.Metadata.DatabaseType)
+	}
+
+	for _, s := range c.config.Canaries {
+		canary, err := config.ParseMaxMindCanary(s)
+		if err != nil {
+			return err
+		}
+		if err := checkCanary(reader, dbType, canary); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Database validated", "type", dbType, "path", path)
+	return nil
+}
+
+// checkCanary runs a canary lookup against an edition that provides the checked field.
+func checkCanary(reader *maxminddb.Reader, dbType DBType, canary config.MaxMindCanary) error {
+	checksCountry := canary.CountryCode != "" && (slices.Contains(countryDBTypes, dbType) || slices.Contains(cityDBTypes, dbType))
+	checksASN := canary.ASN != 0 && (slices.Contains(asnDBTypes, dbType) || dbType == DBTypeEnterprise)
+	if !checksCountry && !checksASN {
+		return nil
+	}
+
+	var record canaryRecord
+	if err := reader.Lookup(canary.IP, &record); err != nil {
+		return fmt.Errorf("%w: canary %s: %w", ErrDBValidationFailed, canary, err)
+	}
+
+	if checksCountry && record.Country.IsoCode != canary.CountryCode {
+		return fmt.Errorf("%w: canary %s returned %q", ErrDBValidationFailed, canary, record.Country.IsoCode)
+	}
+	if checksASN {
+		asn := max(record.AutonomousSystemNumber, record.Traits.AutonomousSystemNumber)
+		if asn != canary.ASN {
+			return fmt.Errorf("%w: canary %s returned AS%d", ErrDBValidationFailed, canary, asn)
+		}
+	}
+	return nil
+}
+
+// editionProduct returns the product of an edition or database type without its GeoLite2 or
+// GeoIP2 prefix, so a GeoIP2-City database is accepted for the GeoLite2-City edition and vice versa.
+func editionProduct(name string) string {
+	product, _ := strings.CutPrefix(name, "GeoLite2-")
+	product, _ = strings.CutPrefix(product, "GeoIP2-")
+	return product
+}
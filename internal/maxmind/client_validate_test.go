@@ -0,0 +1,87 @@
+package maxmind_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeArchive packs a database file into an edition archive like the ones published by MaxMind.
+func writeArchive(t *testing.T, dir string, edition maxmind.DBType, dbPath string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(dbPath)
+	require.NoError(t, err)
+
+	archivePath := filepath.Join(dir, string(edition)+".tar.gz")
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name: filepath.Join(string(edition), string(edition)+".mmdb"),
+		Mode: 0o644,
+		Size: int64(len(b)),
+	}))
+	_, err = tw.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return archivePath
+}
+
+func TestClient_ValidateCandidate(t *testing.T) {
+	dataDir := testhelpers.SetupTestDBDir(t)
+	client := maxmind.NewClient(&config.MaxMindConfig{
+		Canaries: []string{"81.2.69.142 -> GB", "1.128.0.0 -> AS1221"},
+	}, dataDir)
+	defer client.Close()
+
+	_, err := client.ImportArchive(testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz"), "")
+	require.NoError(t, err)
+	_, err = client.ImportArchive(testhelpers.TestDataPath(t, "GeoLite2-ASN.tar.gz"), "")
+	require.NoError(t, err)
+
+	// An ASN database published under the City edition is rejected
+	cityPath := filepath.Join(dataDir, "GeoLite2-City.mmdb")
+	before, err := os.ReadFile(cityPath)
+	require.NoError(t, err)
+
+	archive := writeArchive(t, t.TempDir(), maxmind.DBTypeCity, testhelpers.TestDataPath(t, "GeoLite2-ASN.mmdb"))
+	_, err = client.ImportArchive(archive, "")
+	require.ErrorIs(t, err, maxmind.ErrDBValidationFailed)
+
+	after, err := os.ReadFile(cityPath)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "the current database is kept")
+
+	// A database failing a canary lookup is rejected and reported in the database status
+	client = maxmind.NewClient(&config.MaxMindConfig{
+		Canaries: []string{"81.2.69.142 -> US"},
+	}, dataDir)
+	defer client.Close()
+
+	_, err = client.ImportArchive(testhelpers.TestDataPath(t, "GeoLite2-Country.tar.gz"), "")
+	require.ErrorIs(t, err, maxmind.ErrDBValidationFailed)
+	assert.ErrorContains(t, err, `canary 81.2.69.142 -> US returned "GB"`)
+
+	require.NoError(t, client.Load())
+	for _, m := range client.Metadata() {
+		if m.Name == string(maxmind.DBTypeCountry) {
+			require.NotNil(t, m.LastUpdate)
+			assert.Equal(t, maxmind.UpdateOutcomeFailed, m.LastUpdate.Outcome)
+			assert.Contains(t, m.LastUpdate.Error, maxmind.ErrDBValidationFailed.Error())
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package maxmind
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DBVersion describes a retained version of an edition, identified by its build epoch.
+type DBVersion struct {
+	Version    uint      `json:"version"`
+	BuildEpoch time.Time `json:"build_epoch"`
+	Path       string    `json:"path"`
+	FileSize   int64     `json:"file_size"`
+	Current    bool      `json:"current"`
+	// DiffReport is the path of the report comparing the version with the one it replaced.
+	DiffReport string `json:"diff_report,omitempty"`
+}
+
+// EditionVersions lists the retained versions of an edition, newest first.
+type EditionVersions struct {
+	Edition  DBType      `json:"edition"`
+	Versions []DBVersion `json:"versions"`
+}
+
+// Versions returns the retained versions of all configured editions.
+func (c *Client) Versions() ([]EditionVersions, error) {
+	editions := make([]EditionVersions, 0, len(c.editions()))
+	for _, t := range c.editions() {
+		versions, err := c.editionVersions(t)
+		if err != nil {
+			return nil, err
+		}
+		editions = append(editions, EditionVersions{Edition: t, Versions: versions})
+	}
+	return editions, nil
+}
+
+// Rollback makes a retained version the current version of an edition and reloads the
+// databases. A zero version selects the newest version older than the current one.
+func (c *Client) Rollback(dbType DBType, version uint) (DBVersion, error) {
+	if !slices.Contains(c.editions(), dbType) {
+		return DBVersion{}, fmt.Errorf("%w: %s", ErrEditionNotConfigured, dbType)
+	}
+
+	versions, err := c.editionVersions(dbType)
+	if err != nil {
+		return DBVersion{}, err
+	}
+
+	idx := -1
+	if version == 0 {
+		current := slices.IndexFunc(versions, func(v DBVersion) bool { return v.Current })
+		if current < 0 || current == len(versions)-1 {
+			return DBVersion{}, fmt.Errorf("%w: %s", ErrNoPreviousVersion, dbType)
+		}
+		idx = current + 1
+	} else {
+		idx = slices.IndexFunc(versions, func(v DBVersion) bool { return v.Version == version })
+		if idx < 0 {
+			return DBVersion{}, fmt.Errorf("%w: %s %d", ErrVersionNotFound, dbType, version)
+		}
+	}
+
+	target := versions[idx]
+	if err := c.activateVersion(dbType, target.Version); err != nil {
+		return DBVersion{}, err
+	}
+	slog.Info("Rolled back database", "type", dbType, "version", target.Version)
+
+	if err := c.Load(); err != nil {
+		return DBVersion{}, fmt.Errorf("failed to reload databases: %w", err)
+	}
+
+	target.Current = true
+	return target, nil
+}
+
+// editionVersions returns the retained versions of an edition, newest first.
+func (c *Client) editionVersions(dbType DBType) ([]DBVersion, error) {
+	entries, err := os.ReadDir(c.getVersionsDir(dbType))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []DBVersion{}, nil
+		}
+		return nil, err
+	}
+
+	current := c.currentVersion(dbType)
+	versions := make([]DBVersion, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), "."+DBSuffix)
+		if entry.IsDir() || !ok {
+			continue
+		}
+		version, err := strconv.ParseUint(name, 10, 0)
+		if err != nil {
+			continue
+		}
+
+		v := DBVersion{
+			Version:    uint(version),
+			BuildEpoch: time.Unix(int64(version), 0).UTC(), //nolint:gosec // build epoch is a unix timestamp
+			Path:       c.getVersionPath(dbType, uint(version)),
+			Current:    uint(version) == current,
+		}
+		if info, err := entry.Info(); err == nil {
+			v.FileSize = info.Size()
+		}
+		if _, err := os.Stat(c.getDiffPath(dbType, v.Version, DiffReportSuffix)); err == nil {
+			v.DiffReport = c.getDiffPath(dbType, v.Version, DiffReportSuffix)
+		}
+		versions = append(versions, v)
+	}
+	slices.SortFunc(versions, func(a, b DBVersion) int { return cmp.Compare(b.Version, a.Version) })
+
+	return versions, nil
+}
+
+// storeVersion copies a database file into the versions directory of its edition, named by
+// its build epoch, and returns the version.
+func (c *Client) storeVersion(dbType DBType, path string) (uint, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("%w: type=%s path=%s err=%w", ErrDBOpenFailed, dbType, path, err)
+	}
+	version := reader.Metadata.BuildEpoch
+	_ = reader.Close()
+
+	if err := os.MkdirAll(c.getVersionsDir(dbType), os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	versionPath := c.getVersionPath(dbType, version)
+	tmpPath := versionPath + ".tmp"
+	if err := copyFile(path, tmpPath); err != nil {
+		return 0, err
+	}
+	return version, os.Rename(tmpPath, versionPath)
+}
+
+// activateVersion atomically replaces the database file of an edition with a retained version
+// and points the current pointer at it.
+func (c *Client) activateVersion(dbType DBType, version uint) error {
+	finalDBPath := c.getDBPath(dbType)
+	tmpPath := finalDBPath + ".tmp"
+	if err := copyFile(c.getVersionPath(dbType, version), tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, finalDBPath); err != nil {
+		return err
+	}
+
+	currentPath := filepath.Join(c.getVersionsDir(dbType), CurrentVersionFileName)
+	if err := os.WriteFile(currentPath+".tmp", []byte(strconv.FormatUint(uint64(version), 10)), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(currentPath+".tmp", currentPath)
+}
+
+// pruneVersions removes the oldest versions of an edition beyond the retention limit. The
+// current version is always kept.
+func (c *Client) pruneVersions(dbType DBType) {
+	retain := c.config.RetainVersions
+	if retain < 1 {
+		retain = config.DefaultMaxMindRetainVersions
+	}
+
+	versions, err := c.editionVersions(dbType)
+	if err != nil {
+		slog.Error("Error listing DB versions", "type", dbType, "error", err)
+		return
+	}
+
+	for _, v := range versions[min(retain, len(versions)):] {
+		if v.Current {
+			continue
+		}
+		if err := os.Remove(v.Path); err != nil {
+			slog.Error("Error removing DB version", "type", dbType, "version", v.Version, "error", err)
+		}
+		for _, suffix := range []string{DiffReportSuffix, DiffDetailSuffix} {
+			if err := os.Remove(c.getDiffPath(dbType, v.Version, suffix)); err != nil && !os.IsNotExist(err) {
+				slog.Error("Error removing DB diff", "type", dbType, "version", v.Version, "error", err)
+			}
+		}
+	}
+}
+
+// currentVersion returns the current version of an edition, or 0 if it is unknown.
+func (c *Client) currentVersion(dbType DBType) uint {
+	b, err := os.ReadFile(filepath.Join(c.getVersionsDir(dbType), CurrentVersionFileName))
+	if err != nil {
+		return 0
+	}
+	version, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 0)
+	if err != nil {
+		return 0
+	}
+	return uint(version)
+}
+
+func (c *Client) getVersionsDir(dbType DBType) string {
+	return filepath.Join(c.dataDir, VersionsDirName, string(dbType))
+}
+
+func (c *Client) getVersionPath(dbType DBType, version uint) string {
+	return filepath.Join(c.getVersionsDir(dbType), fmt.Sprintf("%d.%s", version, DBSuffix))
+}
+
+func (c *Client) getDiffPath(dbType DBType, version uint, suffix string) string {
+	return filepath.Join(c.getVersionsDir(dbType), fmt.Sprintf("%d.%s", version, suffix))
+}
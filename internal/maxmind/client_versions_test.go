@@ -0,0 +1,80 @@
+package maxmind_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCityVersion is the build epoch of the city test database.
+const testCityVersion = 1658847190
+
+func TestClient_VersionsAndRollback(t *testing.T) {
+	dataDir := t.TempDir()
+	client := maxmind.NewClient(&config.MaxMindConfig{
+		Editions:       []string{"GeoLite2-City"},
+		RetainVersions: 2,
+	}, dataDir)
+	defer client.Close()
+
+	archive := testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz")
+	_, err := client.ImportArchive(archive, "")
+	require.NoError(t, err)
+
+	versionsDir := filepath.Join(dataDir, maxmind.VersionsDirName, "GeoLite2-City")
+	assert.FileExists(t, filepath.Join(versionsDir, "1658847190.mmdb"))
+
+	// Older releases retained from earlier updates
+	b, err := os.ReadFile(filepath.Join(versionsDir, "1658847190.mmdb"))
+	require.NoError(t, err)
+	for _, name := range []string{"1000.mmdb", "2000.mmdb"} {
+		require.NoError(t, os.WriteFile(filepath.Join(versionsDir, name), b, 0o600))
+	}
+
+	versions, err := client.Versions()
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, maxmind.DBTypeCity, versions[0].Edition)
+	require.Len(t, versions[0].Versions, 3)
+	assert.Equal(t, uint(testCityVersion), versions[0].Versions[0].Version)
+	assert.True(t, versions[0].Versions[0].Current)
+	assert.Equal(t, uint(2000), versions[0].Versions[1].Version)
+
+	version, err := client.Rollback(maxmind.DBTypeCity, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2000), version.Version)
+
+	version, err = client.Rollback(maxmind.DBTypeCity, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1000), version.Version)
+
+	city, err := client.IP2City("81.2.69.142")
+	require.NoError(t, err)
+	assert.Equal(t, "London", city.City)
+
+	_, err = client.Rollback(maxmind.DBTypeCity, 0)
+	require.ErrorIs(t, err, maxmind.ErrNoPreviousVersion)
+
+	_, err = client.Rollback(maxmind.DBTypeCity, 42)
+	require.ErrorIs(t, err, maxmind.ErrVersionNotFound)
+
+	_, err = client.Rollback(maxmind.DBTypeASN, 0)
+	require.ErrorIs(t, err, maxmind.ErrEditionNotConfigured)
+
+	// Installing a release prunes the oldest versions beyond the retention limit
+	_, err = client.ImportArchive(archive, "")
+	require.NoError(t, err)
+
+	versions, err = client.Versions()
+	require.NoError(t, err)
+	require.Len(t, versions[0].Versions, 2)
+	assert.Equal(t, uint(testCityVersion), versions[0].Versions[0].Version)
+	assert.True(t, versions[0].Versions[0].Current)
+	assert.Equal(t, uint(2000), versions[0].Versions[1].Version)
+}
@@ -7,6 +7,13 @@ const (
 	DBArchiveDownloadSuffix    = "tar.gz"
 	DBSHA256FileDownloadSuffix = "tar.gz.sha256"
 	DBSuffix                   = "mmdb"
+	DBUpdateStatusSuffix       = "update.json"
+	IncomingDirName            = "incoming"
+	FailedImportSuffix         = "failed"
+	VersionsDirName            = "versions"
+	CurrentVersionFileName     = "current"
+	DiffReportSuffix           = "diff.json"
+	DiffDetailSuffix           = "diff.ndjson"
 )
 
 var (
@@ -0,0 +1,339 @@
+package maxmind
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DiffChange is how a network differs between two versions of a database.
+type DiffChange string
+
+const (
+	// DiffChangeAdded is a network only found in the new version.
+	DiffChangeAdded DiffChange = "added"
+	// DiffChangeRemoved is a network only found in the old version.
+	DiffChangeRemoved DiffChange = "removed"
+	// DiffChangeChanged is a network whose country, city or ASN changed.
+	DiffChangeChanged DiffChange = "changed"
+)
+
+// DiffRecord holds the fields of a network compared between two versions of a database.
+type DiffRecord struct {
+	ISOCountryCode string `json:"iso_country_code,omitempty"`
+	City           string `json:"city,omitempty"`
+	ASN            uint   `json:"asn,omitempty"`
+	Organization   string `json:"organization,omitempty"`
+}
+
+// DiffEntry is a network that differs between two versions of a database.
+type DiffEntry struct {
+	Network string     `json:"network"`
+	Change  DiffChange `json:"change"`
+	// Fields are the compared fields that changed.
+	Fields []string    `json:"fields,omitempty"`
+	Old    *DiffRecord `json:"old,omitempty"`
+	New    *DiffRecord `json:"new,omitempty"`
+}
+
+// DiffCounts counts the differing networks of a country or ASN.
+type DiffCounts struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	// MovedIn and MovedOut count the networks moved from or to another country or ASN.
+	MovedIn  int `json:"moved_in"`
+	MovedOut int `json:"moved_out"`
+	// Changed counts the networks that kept the country or ASN but changed another field.
+	Changed int `json:"changed"`
+}
+
+// DiffDatabase describes a compared version of a database.
+type DiffDatabase struct {
+	DatabaseType string    `json:"database_type"`
+	BuildEpoch   time.Time `json:"build_epoch"`
+}
+
+// DiffReport summarizes the differences between two versions of a database by country and ASN.
+// Networks are counted after splitting them where either version has a network boundary.
+type DiffReport struct {
+	Old       DiffDatabase           `json:"old"`
+	New       DiffDatabase           `json:"new"`
+	Added     int                    `json:"added"`
+	Removed   int                    `json:"removed"`
+	Changed   int                    `json:"changed"`
+	Countries map[string]*DiffCounts `json:"countries"`
+	ASNs      map[uint]*DiffCounts   `json:"asns"`
+}
+
+// diffSource is the part of a record compared between versions. It uses the keys shared by the
+// GeoIP2 editions, so it decodes from every edition and from built databases.
+type diffSource struct {
+	Country struct {
+		IsoCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		GeoNameID uint `maxminddb:"geoname_id"`
+		Names     struct {
+			English string `maxminddb:"en"`
+		} `maxminddb:"names"`
+	} `maxminddb:"city"`
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// diffCursor walks the networks of a database in address order, as address ranges that the diff
+// consumes from the front.
+type diffCursor struct {
+	networks    *maxminddb.Networks
+	first, last netip.Addr
+	record      DiffRecord
+	cityID      uint
+	done        bool
+}
+
+// next moves to the next network.
+func (d *diffCursor) next() error {
+	if !d.networks.Next() {
+		d.done = true
+		return d.networks.Err()
+	}
+
+	var source diffSource
+	network, err := d.networks.Network(&source)
+	if err != nil {
+		return err
+	}
+	prefix := toPrefix(network)
+	d.first, d.last = prefix.Addr(), lastAddr(prefix)
+	d.record = DiffRecord{
+		ISOCountryCode: source.Country.IsoCode,
+		City:           source.City.Names.English,
+		ASN:            source.AutonomousSystemNumber,
+		Organization:   source.AutonomousSystemOrganization,
+	}
+	d.cityID = source.City.GeoNameID
+	return nil
+}
+
+// consume drops the addresses of the current network up to last.
+func (d *diffCursor) consume(last netip.Addr) error {
+	if last == d.last {
+		return d.next()
+	}
+	d.first = last.Next()
+	return nil
+}
+
+// Diff compares two versions of a database and reports the networks that were added, removed or
+// whose country, city or ASN changed. Cities are compared by GeoNames ID when the database has
+// them. If detail is not nil, every differing network is written to it as an NDJSON DiffEntry.
+func Diff(oldPath, newPath string, detail io.Writer) (*DiffReport, error) {
+	oldReader, err := maxminddb.Open(oldPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: path=%s err=%w", ErrDBOpenFailed, oldPath, err)
+	}
+	defer func() { _ = oldReader.Close() }()
+
+	newReader, err := maxminddb.Open(newPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: path=%s err=%w", ErrDBOpenFailed, newPath, err)
+	}
+	defer func() { _ = newReader.Close() }()
+
+	return diffReaders(context.Background(), oldReader, newReader, detail)
+}
+
+// diffCheckInterval is the number of compared ranges between checks for cancellation.
+const diffCheckInterval = 4096
+
+// diffReaders compares two opened versions of a database, like Diff. It stops with the error of
+// ctx when ctx is done.
+func diffReaders(ctx context.Context, oldReader, newReader *maxminddb.Reader, detail io.Writer) (*DiffReport, error) {
+	if oldReader.Metadata.DatabaseType != newReader.Metadata.DatabaseType {
+		return nil, fmt.Errorf("%w: old=%s new=%s", ErrDiffDatabaseTypeMismatch,
+			oldReader.Metadata.DatabaseType, newReader.Metadata.DatabaseType)
+	}
+
+	report := &DiffReport{
+		Old:       diffDatabase(oldReader),
+		New:       diffDatabase(newReader),
+		Countries: map[string]*DiffCounts{},
+		ASNs:      map[uint]*DiffCounts{},
+	}
+
+	var bw *bufio.Writer
+	var encoder *json.Encoder
+	if detail != nil {
+		bw = bufio.NewWriter(detail)
+		encoder = json.NewEncoder(bw)
+	}
+	emit := func(change DiffChange, first, last netip.Addr, oldRecord, newRecord *DiffRecord, fields []string) error {
+		for _, prefix := range rangeToPrefixes(first, last) {
+			report.count(change, oldRecord, newRecord)
+			if encoder == nil {
+				continue
+			}
+			entry := DiffEntry{Network: prefix.String(), Change: change, Fields: fields, Old: oldRecord, New: newRecord}
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	older := &diffCursor{networks: oldReader.Networks(maxminddb.SkipAliasedNetworks)}
+	newer := &diffCursor{networks: newReader.Networks(maxminddb.SkipAliasedNetworks)}
+	if err := older.next(); err != nil {
+		return nil, err
+	}
+	if err := newer.next(); err != nil {
+		return nil, err
+	}
+
+	// Both versions list their networks in address order, so the ranges are walked side by side,
+	// cutting them where either version starts a network.
+	for i := 0; !older.done || !newer.done; i++ {
+		if i%diffCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		switch {
+		case newer.done || (!older.done && older.first.Less(newer.first)):
+			last := older.last
+			if !newer.done && newer.first.Compare(last) <= 0 {
+				last = newer.first.Prev()
+			}
+			record := older.record
+			err = emit(DiffChangeRemoved, older.first, last, &record, nil, nil)
+			if err == nil {
+				err = older.consume(last)
+			}
+		case older.done || newer.first.Less(older.first):
+			last := newer.last
+			if !older.done && older.first.Compare(last) <= 0 {
+				last = older.first.Prev()
+			}
+			record := newer.record
+			err = emit(DiffChangeAdded, newer.first, last, nil, &record, nil)
+			if err == nil {
+				err = newer.consume(last)
+			}
+		default:
+			last := older.last
+			if newer.last.Less(last) {
+				last = newer.last
+			}
+			if fields := diffFields(older, newer); len(fields) > 0 {
+				oldRecord, newRecord := older.record, newer.record
+				err = emit(DiffChangeChanged, older.first, last, &oldRecord, &newRecord, fields)
+			}
+			if err == nil {
+				err = older.consume(last)
+			}
+			if err == nil {
+				err = newer.consume(last)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if bw != nil {
+		if err := bw.Flush(); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// diffFields returns the compared fields that differ between the current networks of two cursors.
+func diffFields(older, newer *diffCursor) []string {
+	var fields []string
+	if older.record.ISOCountryCode != newer.record.ISOCountryCode {
+		fields = append(fields, "iso_country_code")
+	}
+	if older.cityID != newer.cityID || (older.cityID == 0 && older.record.City != newer.record.City) {
+		fields = append(fields, "city")
+	}
+	if older.record.ASN != newer.record.ASN {
+		fields = append(fields, "asn")
+	}
+	return fields
+}
+
+// count adds a differing network to the totals and to the counts of its countries and ASNs.
+func (r *DiffReport) count(change DiffChange, oldRecord, newRecord *DiffRecord) {
+	country := func(code string) *DiffCounts {
+		if r.Countries[code] == nil {
+			r.Countries[code] = &DiffCounts{}
+		}
+		return r.Countries[code]
+	}
+	asn := func(number uint) *DiffCounts {
+		if r.ASNs[number] == nil {
+			r.ASNs[number] = &DiffCounts{}
+		}
+		return r.ASNs[number]
+	}
+
+	switch change {
+	case DiffChangeAdded:
+		r.Added++
+		if newRecord.ISOCountryCode != "" {
+			country(newRecord.ISOCountryCode).Added++
+		}
+		if newRecord.ASN != 0 {
+			asn(newRecord.ASN).Added++
+		}
+	case DiffChangeRemoved:
+		r.Removed++
+		if oldRecord.ISOCountryCode != "" {
+			country(oldRecord.ISOCountryCode).Removed++
+		}
+		if oldRecord.ASN != 0 {
+			asn(oldRecord.ASN).Removed++
+		}
+	case DiffChangeChanged:
+		r.Changed++
+		if oldRecord.ISOCountryCode == newRecord.ISOCountryCode {
+			if oldRecord.ISOCountryCode != "" {
+				country(oldRecord.ISOCountryCode).Changed++
+			}
+		} else {
+			if oldRecord.ISOCountryCode != "" {
+				country(oldRecord.ISOCountryCode).MovedOut++
+			}
+			if newRecord.ISOCountryCode != "" {
+				country(newRecord.ISOCountryCode).MovedIn++
+			}
+		}
+		if oldRecord.ASN == newRecord.ASN {
+			if oldRecord.ASN != 0 {
+				asn(oldRecord.ASN).Changed++
+			}
+		} else {
+			if oldRecord.ASN != 0 {
+				asn(oldRecord.ASN).MovedOut++
+			}
+			if newRecord.ASN != 0 {
+				asn(newRecord.ASN).MovedIn++
+			}
+		}
+	}
+}
+
+func diffDatabase(reader *maxminddb.Reader) DiffDatabase {
+	return DiffDatabase{
+		DatabaseType: reader.Metadata.DatabaseType,
+		BuildEpoch:   time.Unix(int64(reader.Metadata.BuildEpoch), 0).UTC(), //nolint:gosec // build epoch is a unix timestamp
+	}
+}
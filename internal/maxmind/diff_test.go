@@ -0,0 +1,168 @@
+package maxmind_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestDB builds a database of networks located in countries and announced by an ASN.
+func buildTestDB(t *testing.T, name string, networks map[string]string) string {
+	t.Helper()
+
+	asn := uint(64512)
+	var overlays []maxmind.Overlay
+	for cidr, country := range networks {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		overlays = append(overlays, maxmind.Overlay{Network: network, ISOCountryCode: &country, ASN: &asn})
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = maxmind.BuildMMDB(f, overlays, maxmind.BuildOptions{})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return path
+}
+
+func TestDiff(t *testing.T) {
+	oldPath := buildTestDB(t, "old.mmdb", map[string]string{
+		"10.0.0.0/16": "SE",
+		"10.1.0.0/16": "DE",
+		"10.3.0.0/16": "US",
+	})
+	newPath := buildTestDB(t, "new.mmdb", map[string]string{
+		"10.0.0.0/16":   "SE",
+		"10.1.0.0/17":   "DE",
+		"10.1.128.0/17": "NO",
+		"10.2.0.0/16":   "FR",
+	})
+
+	var detail strings.Builder
+	report, err := maxmind.Diff(oldPath, newPath, &detail)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Added)
+	assert.Equal(t, 1, report.Removed)
+	assert.Equal(t, 1, report.Changed)
+	assert.Equal(t, maxmind.DefaultCustomDatabaseType, report.New.DatabaseType)
+	assert.Equal(t, map[string]*maxmind.DiffCounts{
+		"DE": {MovedOut: 1},
+		"NO": {MovedIn: 1},
+		"FR": {Added: 1},
+		"US": {Removed: 1},
+	}, report.Countries)
+	assert.Equal(t, map[uint]*maxmind.DiffCounts{64512: {Added: 1, Removed: 1, Changed: 1}}, report.ASNs)
+
+	var entries []maxmind.DiffEntry
+	scanner := bufio.NewScanner(strings.NewReader(detail.String()))
+	for scanner.Scan() {
+		var entry maxmind.DiffEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 3)
+	assert.Equal(t, "10.1.128.0/17", entries[0].Network)
+	assert.Equal(t, maxmind.DiffChangeChanged, entries[0].Change)
+	assert.Equal(t, []string{"iso_country_code"}, entries[0].Fields)
+	assert.Equal(t, "DE", entries[0].Old.ISOCountryCode)
+	assert.Equal(t, "NO", entries[0].New.ISOCountryCode)
+	assert.Equal(t, maxmind.DiffChangeAdded, entries[1].Change)
+	assert.Equal(t, "10.2.0.0/16", entries[1].Network)
+	assert.Equal(t, maxmind.DiffChangeRemoved, entries[2].Change)
+	assert.Equal(t, "10.3.0.0/16", entries[2].Network)
+
+	t.Run("unchanged", func(t *testing.T) {
+		path := filepath.Join(testhelpers.SetupTestDBDir(t), "GeoLite2-City.mmdb")
+		report, err := maxmind.Diff(path, path, nil)
+		require.NoError(t, err)
+		assert.Zero(t, report.Added+report.Removed+report.Changed)
+		assert.Empty(t, report.Countries)
+	})
+
+	t.Run("different editions", func(t *testing.T) {
+		dir := testhelpers.SetupTestDBDir(t)
+		_, err := maxmind.Diff(filepath.Join(dir, "GeoLite2-City.mmdb"), filepath.Join(dir, "GeoLite2-ASN.mmdb"), nil)
+		require.ErrorIs(t, err, maxmind.ErrDiffDatabaseTypeMismatch)
+	})
+}
+
+func TestClient_DownloadDiff(t *testing.T) {
+	archive, err := os.ReadFile(testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz"))
+	require.NoError(t, err)
+	sha256, err := os.ReadFile(testhelpers.TestDataPath(t, "GeoLite2-City.tar.gz.sha256"))
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("suffix") == "tar.gz.sha256" {
+			_, _ = w.Write(sha256)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	defer srv.Close()
+
+	downloadURL := maxmind.MaxMindDownloadURL
+	maxmind.MaxMindDownloadURL = srv.URL + maxmind.MaxMindDownloadPathQuery
+	defer func() { maxmind.MaxMindDownloadURL = downloadURL }()
+
+	dataDir := t.TempDir()
+	client := maxmind.NewClient(&config.MaxMindConfig{
+		LicenseKey:     "test-key",
+		Editions:       []string{"GeoLite2-City"},
+		RetainVersions: 1,
+		DiffOnUpdate:   true,
+		DiffDetail:     true,
+	}, dataDir)
+	defer client.Close()
+
+	// The first download has nothing to compare with
+	require.NoError(t, client.DownloadAllDB())
+	versions, err := client.Versions()
+	require.NoError(t, err)
+	require.Len(t, versions[0].Versions, 1)
+	assert.Empty(t, versions[0].Versions[0].DiffReport)
+
+	// An older release is current when the next download replaces it
+	versionsDir := filepath.Join(dataDir, maxmind.VersionsDirName, "GeoLite2-City")
+	b, err := os.ReadFile(filepath.Join(versionsDir, "1658847190.mmdb"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(versionsDir, "1000.mmdb"), b, 0o600))
+	_, err = client.Rollback(maxmind.DBTypeCity, 1000)
+	require.NoError(t, err)
+
+	// The replaced version is compared before it is pruned, and DownloadAllDB waits for the diff
+	require.NoError(t, client.DownloadAllDB())
+	versions, err = client.Versions()
+	require.NoError(t, err)
+	require.Len(t, versions[0].Versions, 1)
+	current := versions[0].Versions[0]
+	require.True(t, current.Current)
+	require.NotEmpty(t, current.DiffReport)
+	assert.NoFileExists(t, filepath.Join(versionsDir, "1000.mmdb"))
+	assert.FileExists(t, filepath.Join(versionsDir, "1658847190.diff.ndjson"))
+	tmp, err := filepath.Glob(filepath.Join(versionsDir, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmp)
+
+	b, err = os.ReadFile(current.DiffReport)
+	require.NoError(t, err)
+	var report maxmind.DiffReport
+	require.NoError(t, json.Unmarshal(b, &report))
+	assert.Equal(t, "GeoIP2-City", report.New.DatabaseType)
+	assert.Zero(t, report.Added+report.Removed+report.Changed)
+}
@@ -0,0 +1,90 @@
+package maxmind
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// earthRadiusKm is the mean radius of the earth.
+	earthRadiusKm = 6371.0088
+
+	// kmPerMile converts kilometers to international miles.
+	kmPerMile = 1.609344
+
+	maxLatitude  = 90
+	maxLongitude = 180
+)
+
+// ErrInvalidCoordinates is returned when coordinates are malformed or out of range.
+var ErrInvalidCoordinates = errors.New("invalid coordinates, expected lat,lon")
+
+// Coordinates is a point on the earth in decimal degrees.
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// ParseCoordinates parses coordinates in the form "lat,lon".
+func ParseCoordinates(s string) (Coordinates, error) {
+	latStr, lonStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return Coordinates{}, ErrInvalidCoordinates
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return Coordinates{}, fmt.Errorf("%w: %q", ErrInvalidCoordinates, s)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil {
+		return Coordinates{}, fmt.Errorf("%w: %q", ErrInvalidCoordinates, s)
+	}
+	// NaN compares false with every bound, so it is rejected explicitly along with infinities.
+	if math.IsNaN(lat) || math.IsNaN(lon) || math.IsInf(lat, 0) || math.IsInf(lon, 0) {
+		return Coordinates{}, fmt.Errorf("%w: %q is not a number", ErrInvalidCoordinates, s)
+	}
+	if math.Abs(lat) > maxLatitude || math.Abs(lon) > maxLongitude {
+		return Coordinates{}, fmt.Errorf("%w: %q is out of range", ErrInvalidCoordinates, s)
+	}
+
+	return Coordinates{Latitude: lat, Longitude: lon}, nil
+}
+
+// DistanceKm returns the great-circle distance to another point in kilometers, using the
+// haversine formula.
+func (c Coordinates) DistanceKm(to Coordinates) float64 {
+	lat1, lat2 := radians(c.Latitude), radians(to.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(to.Longitude - c.Longitude)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BearingTo returns the initial bearing towards another point in degrees clockwise from north,
+// in the range [0, 360).
+func (c Coordinates) BearingTo(to Coordinates) float64 {
+	lat1, lat2 := radians(c.Latitude), radians(to.Latitude)
+	dLon := radians(to.Longitude - c.Longitude)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// KmToMiles converts kilometers to miles.
+func KmToMiles(km float64) float64 {
+	return km / kmPerMile
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
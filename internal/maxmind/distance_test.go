@@ -0,0 +1,37 @@
+package maxmind_test
+
+import (
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCoordinates(t *testing.T) {
+	c, err := maxmind.ParseCoordinates("51.5142, -0.0931")
+	require.NoError(t, err)
+	assert.Equal(t, maxmind.Coordinates{Latitude: 51.5142, Longitude: -0.0931}, c)
+
+	for _, s := range []string{"", "51.5", "a,b", "91,0", "0,-181", "1,2,3", "NaN,0", "0,nan", "+Inf,0", "0,-Inf"} {
+		_, err := maxmind.ParseCoordinates(s)
+		require.ErrorIs(t, err, maxmind.ErrInvalidCoordinates, s)
+	}
+}
+
+func TestCoordinates_Distance(t *testing.T) {
+	london := maxmind.Coordinates{Latitude: 51.5074, Longitude: -0.1278}
+	paris := maxmind.Coordinates{Latitude: 48.8566, Longitude: 2.3522}
+	newYork := maxmind.Coordinates{Latitude: 40.7128, Longitude: -74.0060}
+
+	assert.InDelta(t, 343.6, london.DistanceKm(paris), 1)
+	assert.InDelta(t, 5570, london.DistanceKm(newYork), 5)
+	assert.InDelta(t, london.DistanceKm(newYork), newYork.DistanceKm(london), 1e-9)
+	assert.Zero(t, london.DistanceKm(london))
+	assert.InDelta(t, 213.5, maxmind.KmToMiles(london.DistanceKm(paris)), 1)
+
+	assert.InDelta(t, 148.1, london.BearingTo(paris), 0.5)
+	assert.InDelta(t, 288.3, london.BearingTo(newYork), 0.5)
+	assert.InDelta(t, 0, paris.BearingTo(maxmind.Coordinates{Latitude: 60, Longitude: 2.3522}), 1e-9)
+	assert.InDelta(t, 180, paris.BearingTo(maxmind.Coordinates{Latitude: 0, Longitude: 2.3522}), 1e-9)
+}
@@ -12,6 +12,9 @@ var (
 	// ErrASNDBNotLoaded is returned when the ASN database is not loaded.
 	ErrASNDBNotLoaded = errors.New("ASN database not loaded")
 
+	// ErrDBNotLoaded is returned when the database of an edition is not loaded.
+	ErrDBNotLoaded = errors.New("database not loaded")
+
 	// ErrLicenseKeyRequired is returned when the MaxMind license key is missing.
 	ErrLicenseKeyRequired = errors.New("WAYPOINT_MAXMIND_LICENSE_KEY is required")
 
@@ -27,9 +30,83 @@ var (
 	// ErrDBDownloadFailed is returned when downloading databases fails and no local copies exist.
 	ErrDBDownloadFailed = errors.New("failed to download databases and no existing files found")
 
+	// ErrEditionMismatch is returned when an archive and its sha256 file belong to different editions.
+	ErrEditionMismatch = errors.New("archive and sha256 file editions do not match")
+
+	// ErrEditionNotConfigured is returned when importing an archive of an edition that is not configured.
+	ErrEditionNotConfigured = errors.New("edition is not configured")
+
+	// ErrVersionNotFound is returned when a requested database version is not retained.
+	ErrVersionNotFound = errors.New("database version not found")
+
+	// ErrNoPreviousVersion is returned when rolling back an edition without an older retained version.
+	ErrNoPreviousVersion = errors.New("no previous database version to roll back to")
+
+	// ErrDBValidationFailed is returned when a new database fails validation and is rejected.
+	ErrDBValidationFailed = errors.New("database validation failed")
+
 	// ErrDBOpenFailed is returned when opening a database file fails.
 	ErrDBOpenFailed = errors.New("failed to open database")
 
 	// ErrInvalidIP is returned when an invalid IP address is provided.
 	ErrInvalidIP = errors.New("invalid IP address")
+
+	// ErrNonGlobalIP is returned when looking up an address that is not globally reachable and
+	// such lookups are rejected.
+	ErrNonGlobalIP = errors.New("IP address is not globally reachable")
+
+	// ErrMMDBNotLoaded is returned when the mmdb provider has no database loaded.
+	ErrMMDBNotLoaded = errors.New("no mmdb database loaded")
+
+	// ErrMMDBUnknownPreset is returned when an mmdb database references an unknown field mapping preset.
+	ErrMMDBUnknownPreset = errors.New("unknown mmdb field mapping preset")
+
+	// ErrMMDBNetworkInvalid is returned when a network cannot be stored in a built database, such
+	// as a network within the IPv4 aliases of the IPv6 tree.
+	ErrMMDBNetworkInvalid = errors.New("network cannot be stored in the database")
+
+	// ErrMMDBUnknownField is returned when an mmdb field mapping references an unknown lookup field.
+	ErrMMDBUnknownField = errors.New("unknown mmdb lookup field")
+
+	// ErrNoDataAvailable is returned when none of the databases could answer a lookup.
+	ErrNoDataAvailable = errors.New("no database could resolve the IP address")
+
+	// ErrInvalidCursor is returned when a network query cursor is not an address within the network.
+	ErrInvalidCursor = errors.New("cursor must be an IP address within the network")
+
+	// ErrUnknownField is returned when selecting a field that lookup results do not have.
+	ErrUnknownField = errors.New("unknown lookup field")
+
+	// ErrReverseIndexUnavailable is returned when querying the reverse index before it is built.
+	ErrReverseIndexUnavailable = errors.New("reverse index is not available")
+
+	// ErrASNNotFound is returned when no network of the ASN database belongs to an ASN.
+	ErrASNNotFound = errors.New("ASN not found")
+
+	// ErrCountryNotFound is returned when no network of the country database is located in a country.
+	ErrCountryNotFound = errors.New("country not found")
+
+	// ErrInvalidASN is returned when parsing an ASN that is not a 32-bit number.
+	ErrInvalidASN = errors.New("invalid ASN, expected a number such as 13335 or AS13335")
+
+	// ErrInvalidCountryCode is returned when parsing a country that is not an ISO 3166-1 alpha-2 code.
+	ErrInvalidCountryCode = errors.New("invalid country, expected an ISO 3166-1 alpha-2 code such as SE")
+
+	// ErrUnknownACLFormat is returned when generating an access control list in an unknown format.
+	ErrUnknownACLFormat = errors.New("unknown ACL format")
+
+	// ErrInvalidACLName is returned when an access control list name is not usable in every format.
+	ErrInvalidACLName = errors.New("ACL name must start with a letter and contain up to 28 letters, digits or underscores")
+
+	// ErrACLFilterRequired is returned when generating an access control list without countries or ASNs.
+	ErrACLFilterRequired = errors.New("at least one country or ASN is required")
+
+	// ErrACLEmpty is returned when no network matches the countries and ASNs of an access control list.
+	ErrACLEmpty = errors.New("no network matches the countries and ASNs")
+
+	// ErrUnknownExportFormat is returned when exporting a database in an unknown format.
+	ErrUnknownExportFormat = errors.New("unknown export format")
+
+	// ErrDiffDatabaseTypeMismatch is returned when comparing databases of different editions.
+	ErrDiffDatabaseTypeMismatch = errors.New("cannot compare databases of different types")
 )
@@ -0,0 +1,252 @@
+package maxmind
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// ExportFormat is the file format of a database export.
+type ExportFormat string
+
+const (
+	// ExportFormatCSV is a CSV file with a header row. Nested fields are written as JSON.
+	ExportFormatCSV ExportFormat = "csv"
+	// ExportFormatNDJSON is one JSON object per line.
+	ExportFormatNDJSON ExportFormat = "ndjson"
+)
+
+// ExportFormats are the supported database export formats.
+var ExportFormats = []ExportFormat{ExportFormatCSV, ExportFormatNDJSON}
+
+// ExportOptions configure a database export.
+type ExportOptions struct {
+	Format ExportFormat
+	// IPVersion limits the export to IPv4 (4) or IPv6 (6) networks. Zero exports both.
+	IPVersion int
+	// Language is the language of place names.
+	Language string
+}
+
+// ParseExportFormat parses the name of a database export format.
+func ParseExportFormat(s string) (ExportFormat, error) {
+	format := ExportFormat(strings.ToLower(strings.TrimSpace(s)))
+	if !slices.Contains(ExportFormats, format) {
+		return "", fmt.Errorf("%w: %s", ErrUnknownExportFormat, s)
+	}
+	return format, nil
+}
+
+// exportFields returns the lookup fields answered by an edition, in the order they are exported.
+func exportFields(t DBType) ([]string, error) {
+	fields := []string{"network"}
+	switch {
+	case t == DBTypeEnterprise:
+		fields = slices.Concat(fields, countryFields, cityFields, asnFields, []string{"isp", "connection_type", "domain"}, enterpriseFields)
+	case slices.Contains(countryDBTypes, t):
+		fields = append(fields, countryFields...)
+	case slices.Contains(cityDBTypes, t):
+		fields = slices.Concat(fields, countryFields, cityFields)
+	case t == DBTypeASN:
+		fields = append(fields, asnFields...)
+	case t == DBTypeISP:
+		fields = slices.Concat(fields, asnFields, []string{"isp"})
+	case t == DBTypeConnectionType:
+		fields = append(fields, "connection_type")
+	case t == DBTypeAnonymousIP:
+		fields = append(fields, anonymousIPFields...)
+	case t == DBTypeDomain:
+		fields = append(fields, "domain")
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrEditionNotConfigured, t)
+	}
+	return fields, nil
+}
+
+// Export writes every network of a loaded edition with the fields the edition answers, in the
+// shape of lookup results, and returns the number of networks written. Loads wait for the export
+// to finish.
+func (c *Client) Export(t DBType, w io.Writer, opts ExportOptions) (int, error) {
+	fields, err := exportFields(t)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	reader, ok := c.readers[t]
+	if !ok || reader == nil {
+		return 0, fmt.Errorf("%w: %s", ErrDBNotLoaded, t)
+	}
+
+	var write func(map[string]json.RawMessage) error
+	var cw *csv.Writer
+	bw := bufio.NewWriter(w)
+	switch opts.Format {
+	case ExportFormatCSV:
+		cw = csv.NewWriter(bw)
+		if err := cw.Write(fields); err != nil {
+			return 0, err
+		}
+		row := make([]string, len(fields))
+		write = func(values map[string]json.RawMessage) error {
+			for i, f := range fields {
+				row[i] = csvValue(values[f])
+			}
+			return cw.Write(row)
+		}
+	case ExportFormatNDJSON:
+		var line bytes.Buffer
+		write = func(values map[string]json.RawMessage) error {
+			line.Reset()
+			line.WriteByte('{')
+			for i, f := range fields {
+				if i > 0 {
+					line.WriteByte(',')
+				}
+				fmt.Fprintf(&line, "%q:", f)
+				// Fields omitted when empty are written as null, so every line has the same keys.
+				if v, ok := values[f]; ok {
+					line.Write(v)
+				} else {
+					line.WriteString("null")
+				}
+			}
+			line.WriteString("}\n")
+			_, err := bw.Write(line.Bytes())
+			return err
+		}
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrUnknownExportFormat, opts.Format)
+	}
+
+	count := 0
+	networks := reader.Networks(maxminddb.SkipAliasedNetworks)
+	for networks.Next() {
+		geoIP, network, err := exportRecord(t, reader, networks, opts.Language)
+		if err != nil {
+			return count, err
+		}
+		if is4 := network.IP.To4() != nil; (opts.IPVersion == 4 && !is4) || (opts.IPVersion == 6 && is4) {
+			continue
+		}
+		geoIP.Network = toPrefix(network).String()
+
+		values, err := SelectFields(geoIP, fields)
+		if err != nil {
+			return count, err
+		}
+		if err := write(values); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := networks.Err(); err != nil {
+		return count, err
+	}
+
+	if cw != nil {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return count, err
+		}
+	}
+	return count, bw.Flush()
+}
+
+// exportRecord decodes the record of the current network into a lookup result.
+func exportRecord(t DBType, reader *maxminddb.Reader, networks *maxminddb.Networks, lang string) (GeoIP, *net.IPNet, error) {
+	var geoIP GeoIP
+	switch {
+	case t == DBTypeEnterprise:
+		var record geoip2.Enterprise
+		network, err := networks.Network(&record)
+		if err != nil {
+			return geoIP, nil, err
+		}
+		// The location is mapped the same way as from the City editions.
+		var city geoip2.City
+		if err := reader.Lookup(network.IP, &city); err != nil {
+			return geoIP, nil, err
+		}
+		geoIP.IPCity = newIPCity(&city, lang)
+		geoIP.ASN = record.Traits.AutonomousSystemNumber
+		geoIP.Organization = record.Traits.AutonomousSystemOrganization
+		geoIP.ISP = record.Traits.ISP
+		geoIP.ConnectionType = record.Traits.ConnectionType
+		geoIP.Domain = record.Traits.Domain
+		geoIP.UserType = record.Traits.UserType
+		geoIP.Confidence = &Confidence{
+			Country:    record.Country.Confidence,
+			City:       record.City.Confidence,
+			PostalCode: record.Postal.Confidence,
+		}
+		if len(record.Subdivisions) > 0 {
+			geoIP.Confidence.Subdivision = record.Subdivisions[0].Confidence
+		}
+		return geoIP, network, nil
+	case slices.Contains(countryDBTypes, t):
+		var record geoip2.Country
+		network, err := networks.Network(&record)
+		geoIP.IPCountry = newIPCountry(&record, lang)
+		return geoIP, network, err
+	case slices.Contains(cityDBTypes, t):
+		var record geoip2.City
+		network, err := networks.Network(&record)
+		geoIP.IPCity = newIPCity(&record, lang)
+		return geoIP, network, err
+	case t == DBTypeASN:
+		var record geoip2.ASN
+		network, err := networks.Network(&record)
+		geoIP.ASN = record.AutonomousSystemNumber
+		geoIP.Organization = record.AutonomousSystemOrganization
+		return geoIP, network, err
+	case t == DBTypeISP:
+		var record geoip2.ISP
+		network, err := networks.Network(&record)
+		geoIP.ASN = record.AutonomousSystemNumber
+		geoIP.Organization = record.AutonomousSystemOrganization
+		geoIP.ISP = record.ISP
+		return geoIP, network, err
+	case t == DBTypeConnectionType:
+		var record geoip2.ConnectionType
+		network, err := networks.Network(&record)
+		geoIP.ConnectionType = record.ConnectionType
+		return geoIP, network, err
+	case t == DBTypeAnonymousIP:
+		var record geoip2.AnonymousIP
+		network, err := networks.Network(&record)
+		geoIP.IsVPN = &record.IsAnonymousVPN
+		geoIP.IsTor = &record.IsTorExitNode
+		geoIP.IsHosting = &record.IsHostingProvider
+		return geoIP, network, err
+	default:
+		var record geoip2.Domain
+		network, err := networks.Network(&record)
+		geoIP.Domain = record.Domain
+		return geoIP, network, err
+	}
+}
+
+// csvValue returns a field as a CSV cell: strings unquoted, missing fields empty and other
+// values as JSON.
+func csvValue(v json.RawMessage) string {
+	if len(v) == 0 || string(v) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return s
+	}
+	return string(v)
+}
@@ -0,0 +1,78 @@
+package maxmind_test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Export(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	t.Run("csv", func(t *testing.T) {
+		var b strings.Builder
+		count, err := client.Export(maxmind.DBTypeCity, &b, maxmind.ExportOptions{Format: maxmind.ExportFormatCSV, IPVersion: 4})
+		require.NoError(t, err)
+
+		rows, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, count+1)
+		header := rows[0]
+		assert.Equal(t, "network", header[0])
+		assert.Contains(t, header, "subdivisions")
+		assert.NotContains(t, header, "asn")
+
+		var milton map[string]string
+		for _, row := range rows[1:] {
+			assert.NotContains(t, row[0], ":")
+			if row[0] == "216.160.83.56/29" {
+				milton = make(map[string]string)
+				for i, f := range header {
+					milton[f] = row[i]
+				}
+			}
+		}
+		require.NotNil(t, milton)
+		assert.Equal(t, "Milton", milton["city"])
+		assert.Equal(t, "US", milton["iso_country_code"])
+		assert.Equal(t, `[{"iso_code":"WA","name":"Washington"}]`, milton["subdivisions"])
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		var b strings.Builder
+		count, err := client.Export(maxmind.DBTypeASN, &b, maxmind.ExportOptions{Format: maxmind.ExportFormatNDJSON, IPVersion: 6})
+		require.NoError(t, err)
+		assert.Positive(t, count)
+
+		lines := 0
+		scanner := bufio.NewScanner(strings.NewReader(b.String()))
+		for scanner.Scan() {
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+			assert.Len(t, record, 3)
+			assert.Contains(t, record["network"], ":")
+			lines++
+		}
+		assert.Equal(t, count, lines)
+	})
+
+	t.Run("not loaded", func(t *testing.T) {
+		_, err := client.Export(maxmind.DBTypeDomain, &strings.Builder{}, maxmind.ExportOptions{Format: maxmind.ExportFormatCSV})
+		require.ErrorIs(t, err, maxmind.ErrDBNotLoaded)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := maxmind.ParseExportFormat("parquet")
+		require.ErrorIs(t, err, maxmind.ErrUnknownExportFormat)
+	})
+}
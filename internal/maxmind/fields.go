@@ -0,0 +1,134 @@
+package maxmind
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+var (
+	// countryFields are the fields answered by the Country editions, and by the City editions.
+	countryFields = []string{
+		"country", "continent", "iso_country_code", "iso_continent_code", "is_anonymous_proxy", "is_satellite_provider",
+	}
+
+	// cityFields are the fields only answered by the City editions.
+	cityFields = []string{
+		"city", "timezone", "latitude", "longitude", "accuracy_radius_km", "postal_code", "subdivisions", "metro_code",
+	}
+
+	// asnFields are the fields answered by the ASN editions.
+	asnFields = []string{"asn", "organization"}
+
+	// enterpriseFields are the traits only answered by the GeoIP2 Enterprise edition.
+	enterpriseFields = []string{"user_type", "confidence"}
+
+	// anonymousIPFields are the traits answered by the GeoIP2 Anonymous IP edition.
+	anonymousIPFields = []string{"is_vpn", "is_tor", "is_hosting"}
+
+	// customFields are the fields answered by the custom editions.
+	customFields = []string{"country", "iso_country_code", "city", "latitude", "longitude", "asn", "organization", "tags"}
+
+	// networkFields describe the networks matched by every queried database, so selecting
+	// them queries all databases.
+	networkFields = []string{"network", "networks"}
+
+	// Fields are the fields of a lookup result that can be selected.
+	Fields = jsonFields(reflect.TypeFor[GeoIP]())
+)
+
+// jsonFields returns the JSON keys of a struct, including those of embedded structs.
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Anonymous {
+			fields = append(fields, jsonFields(f.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	slices.Sort(fields)
+	return slices.Compact(fields)
+}
+
+// ParseFields parses a comma-separated list of fields to select. An empty list selects all fields.
+func ParseFields(s string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !slices.Contains(Fields, f) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownField, f)
+		}
+		if !slices.Contains(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// SelectFields returns the selected fields of a lookup result as a JSON object. An empty
+// selection returns all fields.
+func SelectFields(geoIP GeoIP, fields []string) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(geoIP)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return all, nil
+	}
+
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		if v, ok := all[f]; ok {
+			selected[f] = v
+		}
+	}
+	return selected, nil
+}
+
+// WithFields limits a lookup to the given fields. Databases that answer none of them are not
+// queried, so the other fields of the result are left empty.
+func WithFields(fields ...string) LookupOption {
+	return func(o *lookupOptions) {
+		o.fields = fields
+	}
+}
+
+// wants reports whether any of the fields is selected. Every field is wanted when no field or
+// a network field is selected.
+func (o lookupOptions) wants(fields ...string) bool {
+	if len(o.fields) == 0 || slices.ContainsFunc(networkFields, func(f string) bool { return slices.Contains(o.fields, f) }) {
+		return true
+	}
+	for _, f := range fields {
+		if slices.Contains(o.fields, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheKey identifies the result of a lookup with these options.
+func (o lookupOptions) cacheKey(ipStr string) string {
+	fields := slices.Clone(o.fields)
+	slices.Sort(fields)
+	key := ipStr + "|" + o.language + "|" + strings.Join(fields, ",")
+	if o.specialPurpose {
+		key += "|special"
+	}
+	return key
+}
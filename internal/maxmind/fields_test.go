@@ -0,0 +1,86 @@
+package maxmind_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := maxmind.ParseFields(" iso_country_code, asn,,asn")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"iso_country_code", "asn"}, fields)
+
+	fields, err = maxmind.ParseFields("")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	_, err = maxmind.ParseFields("country,IPCity")
+	require.ErrorIs(t, err, maxmind.ErrUnknownField)
+
+	assert.Contains(t, maxmind.Fields, "accuracy_radius_km")
+	assert.Contains(t, maxmind.Fields, "is_vpn")
+	assert.NotContains(t, maxmind.Fields, "IPASN")
+}
+
+func TestSelectFields(t *testing.T) {
+	geoIP := maxmind.GeoIP{IP: "81.2.69.142"}
+	geoIP.ISOCountryCode = "GB"
+	geoIP.ASN = 1
+
+	selected, err := maxmind.SelectFields(geoIP, []string{"iso_country_code", "asn"})
+	require.NoError(t, err)
+	b, err := json.Marshal(selected)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"iso_country_code": "GB", "asn": 1}`, string(b))
+
+	// Selected fields that are omitted when empty stay omitted
+	selected, err = maxmind.SelectFields(geoIP, []string{"ptr"})
+	require.NoError(t, err)
+	assert.Empty(t, selected)
+}
+
+func TestClient_LookupFields(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	// 216.160.83.56 is Milton, WA, US (AS209) in the MaxMind test databases
+	ip := "216.160.83.56"
+
+	// Country fields are answered by the Country edition alone
+	geo, err := client.IP2Geo(ip, maxmind.WithFields("iso_country_code"))
+	require.NoError(t, err)
+	assert.Equal(t, "US", geo.ISOCountryCode)
+	assert.Empty(t, geo.City)
+	assert.Zero(t, geo.ASN)
+	assert.Equal(t, map[maxmind.DBType]string{maxmind.DBTypeCountry: "216.160.83.56/29"}, geo.Networks)
+
+	geo, err = client.IP2Geo(ip, maxmind.WithFields("asn", "organization"))
+	require.NoError(t, err)
+	assert.Equal(t, uint(209), geo.ASN)
+	assert.Empty(t, geo.ISOCountryCode)
+	assert.Equal(t, map[maxmind.DBType]string{maxmind.DBTypeASN: "216.160.64.0/18"}, geo.Networks)
+	assert.Equal(t, "216.160.64.0/18", geo.Network)
+
+	geo, err = client.IP2Geo(ip, maxmind.WithFields("city", "iso_country_code"))
+	require.NoError(t, err)
+	assert.Equal(t, "Milton", geo.City)
+	assert.Equal(t, "US", geo.ISOCountryCode)
+	assert.Zero(t, geo.ASN)
+	assert.Equal(t, map[maxmind.DBType]string{maxmind.DBTypeCity: "216.160.83.56/29"}, geo.Networks)
+
+	// The network fields describe the networks of all databases
+	for _, field := range []string{"network", "networks"} {
+		geo, err = client.IP2Geo(ip, maxmind.WithFields(field))
+		require.NoError(t, err)
+		assert.Equal(t, "216.160.83.56/29", geo.Network)
+		assert.Equal(t, "216.160.64.0/18", geo.Networks[maxmind.DBTypeASN])
+		assert.Equal(t, "216.160.83.56/29", geo.Networks[maxmind.DBTypeCity])
+	}
+}
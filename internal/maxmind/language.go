@@ -0,0 +1,53 @@
+package maxmind
+
+import (
+	"golang.org/x/text/language"
+)
+
+// DefaultLanguage is the language used when no supported language is requested.
+const DefaultLanguage = "en"
+
+// SupportedLanguages lists the locales shipped with the GeoIP2/GeoLite2 databases.
+// The first entry is the fallback language.
+var SupportedLanguages = []string{DefaultLanguage, "de", "es", "fr", "ja", "pt-BR", "ru", "zh-CN"}
+
+var languageMatcher = newLanguageMatcher()
+
+func newLanguageMatcher() language.Matcher {
+	tags := make([]language.Tag, 0, len(SupportedLanguages))
+	for _, l := range SupportedLanguages {
+		tags = append(tags, language.MustParse(l))
+	}
+	return language.NewMatcher(tags)
+}
+
+// NegotiateLanguage picks the supported language that best matches the explicitly requested
+// language and, failing that, the given Accept-Language header value. It falls back to
+// DefaultLanguage when neither yields a match.
+func NegotiateLanguage(lang, acceptLanguage string) string {
+	if lang != "" {
+		if tag, err := language.Parse(lang); err == nil {
+			if _, idx, conf := languageMatcher.Match(tag); conf != language.No {
+				return SupportedLanguages[idx]
+			}
+		}
+	}
+
+	if acceptLanguage != "" {
+		if tags, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil && len(tags) > 0 {
+			if _, idx, conf := languageMatcher.Match(tags...); conf != language.No {
+				return SupportedLanguages[idx]
+			}
+		}
+	}
+
+	return DefaultLanguage
+}
+
+// localizedName returns the name for the given language, falling back to English.
+func localizedName(names map[string]string, lang string) string {
+	if name, ok := names[lang]; ok && name != "" {
+		return name
+	}
+	return names[DefaultLanguage]
+}
@@ -0,0 +1,65 @@
+package maxmind_test
+
+import (
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateLanguage(t *testing.T) {
+	testCases := []struct {
+		name           string
+		lang           string
+		acceptLanguage string
+		expected       string
+	}{
+		{
+			name:     "no preference",
+			expected: "en",
+		},
+		{
+			name:     "exact lang",
+			lang:     "de",
+			expected: "de",
+		},
+		{
+			name:     "lang without region",
+			lang:     "pt",
+			expected: "pt-BR",
+		},
+		{
+			name:           "lang takes precedence over accept-language",
+			lang:           "fr",
+			acceptLanguage: "ja",
+			expected:       "fr",
+		},
+		{
+			name:           "unsupported lang falls back to accept-language",
+			lang:           "xx",
+			acceptLanguage: "ja-JP,ja;q=0.9,en;q=0.8",
+			expected:       "ja",
+		},
+		{
+			name:           "accept-language quality ordering",
+			acceptLanguage: "da;q=1.0, ru;q=0.8, de;q=0.5",
+			expected:       "ru",
+		},
+		{
+			name:           "unsupported accept-language",
+			acceptLanguage: "xx",
+			expected:       "en",
+		},
+		{
+			name:           "malformed accept-language",
+			acceptLanguage: ";;;",
+			expected:       "en",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, maxmind.NegotiateLanguage(tc.lang, tc.acceptLanguage))
+		})
+	}
+}
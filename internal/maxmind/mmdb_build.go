@@ -0,0 +1,112 @@
+package maxmind
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/inserter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+// DefaultCustomDatabaseType is the database type of built databases.
+const DefaultCustomDatabaseType = "Waypoint-Custom"
+
+// customRecord is the record of a built database. It uses the keys of the GeoIP2 City and ASN
+// editions, so tools reading those read it as well.
+type customRecord struct {
+	Country struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+	AutonomousSystemNumber       *uint             `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string            `maxminddb:"autonomous_system_organization"`
+	Tags                         map[string]string `maxminddb:"tags"`
+}
+
+// BuildOptions configure a built database.
+type BuildOptions struct {
+	// DatabaseType is written to the metadata, defaulting to DefaultCustomDatabaseType.
+	DatabaseType string
+	// Description is the English description written to the metadata.
+	Description string
+}
+
+// BuildMMDB compiles overlays into a MaxMind DB file, which can be loaded as a custom edition and
+// read by any MaxMind DB reader. Where overlays overlap, the most specific one wins for each field,
+// as when they are merged into lookups. It returns the number of bytes written.
+func BuildMMDB(w io.Writer, overlays []Overlay, opts BuildOptions) (int64, error) {
+	tree, err := mmdbwriter.New(mmdbwriter.Options{
+		DatabaseType:            cmp.Or(opts.DatabaseType, DefaultCustomDatabaseType),
+		Description:             map[string]string{DefaultLanguage: opts.Description},
+		Languages:               []string{DefaultLanguage},
+		IncludeReservedNetworks: true,
+		Inserter:                inserter.DeepMergeWith,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Inserted from the least to the most specific network, so the more specific ones are merged
+	// over the others.
+	sorted := slices.Clone(overlays)
+	slices.SortStableFunc(sorted, func(a, b Overlay) int {
+		ones, bits := a.Network.Mask.Size()
+		otherOnes, otherBits := b.Network.Mask.Size()
+		return cmp.Compare(overlayPrefix(ones, bits), overlayPrefix(otherOnes, otherBits))
+	})
+	for _, o := range sorted {
+		if err := tree.Insert(o.Network, overlayRecord(o)); err != nil {
+			return 0, fmt.Errorf("%w: %s: %w", ErrMMDBNetworkInvalid, o.Network, err)
+		}
+	}
+
+	return tree.WriteTo(w)
+}
+
+// overlayRecord returns the record of an overlay in the layout of customRecord.
+func overlayRecord(o Overlay) mmdbtype.Map {
+	record := mmdbtype.Map{}
+	country := mmdbtype.Map{}
+	if o.ISOCountryCode != nil {
+		country["iso_code"] = mmdbtype.String(*o.ISOCountryCode)
+	}
+	if o.Country != nil {
+		country["names"] = mmdbtype.Map{DefaultLanguage: mmdbtype.String(*o.Country)}
+	}
+	if len(country) > 0 {
+		record["country"] = country
+	}
+	if o.City != nil {
+		record["city"] = mmdbtype.Map{"names": mmdbtype.Map{DefaultLanguage: mmdbtype.String(*o.City)}}
+	}
+	if o.Latitude != nil && o.Longitude != nil {
+		record["location"] = mmdbtype.Map{
+			"latitude":  mmdbtype.Float64(*o.Latitude),
+			"longitude": mmdbtype.Float64(*o.Longitude),
+		}
+	}
+	if o.ASN != nil {
+		record["autonomous_system_number"] = mmdbtype.Uint32(*o.ASN) //nolint:gosec // ASNs are 32-bit
+	}
+	if o.Organization != nil {
+		record["autonomous_system_organization"] = mmdbtype.String(*o.Organization)
+	}
+	if len(o.Tags) > 0 {
+		tags := mmdbtype.Map{}
+		for k, v := range o.Tags {
+			tags[mmdbtype.String(k)] = mmdbtype.String(v)
+		}
+		record["tags"] = tags
+	}
+	return record
+}
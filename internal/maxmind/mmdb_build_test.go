@@ -0,0 +1,77 @@
+package maxmind_test
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMMDB(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+	asn := uint(64512)
+	_, wide, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	_, narrow, err := net.ParseCIDR("10.1.0.0/16")
+	require.NoError(t, err)
+
+	overlays := []maxmind.Overlay{
+		{Network: narrow, City: ptr("Berlin"), ISOCountryCode: ptr("DE"), Tags: map[string]string{"site": "ber1"}},
+		{Network: wide, ASN: &asn, Organization: ptr("Example Corp"), Tags: map[string]string{"team": "netops", "site": "any"}},
+	}
+
+	dataDir := testhelpers.SetupTestDBDir(t)
+	f, err := os.Create(filepath.Join(dataDir, "corp.mmdb"))
+	require.NoError(t, err)
+	n, err := maxmind.BuildMMDB(f, overlays, maxmind.BuildOptions{Description: "test"})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Positive(t, n)
+
+	client := maxmind.NewClient(&config.MaxMindConfig{CustomEditions: []string{"corp"}}, dataDir)
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	t.Run("most specific wins", func(t *testing.T) {
+		geoIP, err := client.IP2Geo("10.1.2.3")
+		require.NoError(t, err)
+		assert.Equal(t, "Berlin", geoIP.City)
+		assert.Equal(t, "DE", geoIP.ISOCountryCode)
+		assert.Equal(t, asn, geoIP.ASN)
+		assert.Equal(t, "Example Corp", geoIP.Organization)
+		assert.Equal(t, map[string]string{"site": "ber1", "team": "netops"}, geoIP.Tags)
+		assert.Equal(t, "10.1.0.0/16", geoIP.Networks["corp"])
+	})
+
+	t.Run("less specific network", func(t *testing.T) {
+		geoIP, err := client.IP2Geo("10.2.0.1")
+		require.NoError(t, err)
+		assert.Empty(t, geoIP.City)
+		assert.Equal(t, asn, geoIP.ASN)
+		assert.Equal(t, map[string]string{"site": "any", "team": "netops"}, geoIP.Tags)
+	})
+
+	t.Run("merged with GeoLite2", func(t *testing.T) {
+		geoIP, err := client.IP2Geo("216.160.83.56")
+		require.NoError(t, err)
+		assert.Equal(t, "Milton", geoIP.City)
+		assert.Empty(t, geoIP.Tags)
+		assert.NotContains(t, geoIP.Networks, maxmind.DBType("corp"))
+		assert.Equal(t, "216.160.83.56/29", geoIP.Network)
+	})
+}
+
+func TestBuildMMDB_InvalidNetwork(t *testing.T) {
+	_, aliased, err := net.ParseCIDR("2002:a00::/24")
+	require.NoError(t, err)
+
+	_, err = maxmind.BuildMMDB(io.Discard, []maxmind.Overlay{{Network: aliased}}, maxmind.BuildOptions{})
+	require.ErrorIs(t, err, maxmind.ErrMMDBNetworkInvalid)
+}
@@ -0,0 +1,39 @@
+package maxmind
+
+// LookupOption configures a single lookup.
+type LookupOption func(*lookupOptions)
+
+type lookupOptions struct {
+	language string
+	fields   []string
+	// specialPurpose marks the lookup of an address that is not globally reachable, which only
+	// databases describing private networks can answer.
+	specialPurpose bool
+}
+
+func newLookupOptions(opts ...LookupOption) lookupOptions {
+	o := lookupOptions{
+		language: DefaultLanguage,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithLanguage sets the language used for place names. Names missing in that
+// language fall back to English.
+func WithLanguage(lang string) LookupOption {
+	return func(o *lookupOptions) {
+		if lang != "" {
+			o.language = lang
+		}
+	}
+}
+
+// withSpecialPurpose marks the lookup of an address classified as special-purpose.
+func withSpecialPurpose() LookupOption {
+	return func(o *lookupOptions) {
+		o.specialPurpose = true
+	}
+}
@@ -0,0 +1,177 @@
+package maxmind
+
+import (
+	"maps"
+	"net"
+	"slices"
+	"sync"
+)
+
+// Overlay overrides lookup fields for the addresses of a network. Nil fields are not overridden.
+type Overlay struct {
+	Network        *net.IPNet
+	Country        *string
+	ISOCountryCode *string
+	City           *string
+	Latitude       *float64
+	Longitude      *float64
+	ASN            *uint
+	Organization   *string
+	Tags           map[string]string
+}
+
+// OverlayResult describes the overlays merged into a lookup result.
+type OverlayResult struct {
+	// Networks are the matched overlay networks, most specific first.
+	Networks []string `json:"networks"`
+	// Fields are the lookup fields whose values were overridden.
+	Fields []string `json:"overridden_fields"`
+}
+
+// OverlaySet holds overlays indexed by prefix length for longest-prefix matching.
+type OverlaySet struct {
+	mu       sync.RWMutex
+	byPrefix map[int]map[string]Overlay
+	prefixes []int
+}
+
+// NewOverlaySet creates an empty overlay set.
+func NewOverlaySet() *OverlaySet {
+	return &OverlaySet{
+		byPrefix: make(map[int]map[string]Overlay),
+	}
+}
+
+// Replace atomically replaces all overlays of the set.
+func (s *OverlaySet) Replace(overlays []Overlay) {
+	byPrefix := make(map[int]map[string]Overlay)
+	for _, o := range overlays {
+		ones, bits := o.Network.Mask.Size()
+		prefix := overlayPrefix(ones, bits)
+		if byPrefix[prefix] == nil {
+			byPrefix[prefix] = make(map[string]Overlay)
+		}
+		byPrefix[prefix][o.Network.IP.To16().String()] = o
+	}
+
+	prefixes := slices.Sorted(maps.Keys(byPrefix))
+	slices.Reverse(prefixes)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byPrefix = byPrefix
+	s.prefixes = prefixes
+}
+
+// Match returns the overlays whose network contains ip, most specific first.
+func (s *OverlaySet) Match(ip net.IP) []Overlay {
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []Overlay
+	for _, prefix := range s.prefixes {
+		// IPv4 overlays are stored as IPv4-mapped IPv6 networks.
+		masked := ip16.Mask(net.CIDRMask(prefix, 8*net.IPv6len))
+		if o, ok := s.byPrefix[prefix][masked.String()]; ok {
+			matches = append(matches, o)
+		}
+	}
+	return matches
+}
+
+// overlayPrefix converts a prefix length to its IPv6 equivalent, so IPv4 and IPv6 overlays
+// share one index.
+func overlayPrefix(ones, bits int) int {
+	if bits == 8*net.IPv4len {
+		return ones + 8*(net.IPv6len-net.IPv4len)
+	}
+	return ones
+}
+
+// OverlayProvider merges overlays on top of the lookup results of another provider.
+type OverlayProvider struct {
+	Provider
+	overlays *OverlaySet
+}
+
+// NewOverlayProvider wraps a provider with an overlay set.
+func NewOverlayProvider(p Provider, overlays *OverlaySet) *OverlayProvider {
+	return &OverlayProvider{
+		Provider: p,
+		overlays: overlays,
+	}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *OverlayProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// IP2Geo looks up an IP address and merges the matching overlays into the result. When several
+// overlays match, the most specific one wins for each field. Overlays also answer addresses the
+// wrapped provider fails to, such as rejected private addresses or addresses without data.
+func (p *OverlayProvider) IP2Geo(ipStr string, opts ...LookupOption) (GeoIP, error) {
+	geoIP, err := p.Provider.IP2Geo(ipStr, opts...)
+
+	matches := p.overlays.Match(net.ParseIP(ipStr))
+	if len(matches) == 0 {
+		return geoIP, err
+	}
+
+	result := &OverlayResult{Networks: make([]string, 0, len(matches))}
+	overridden := make(map[string]bool)
+	set := func(field string, apply func()) {
+		apply()
+		if !overridden[field] {
+			overridden[field] = true
+			result.Fields = append(result.Fields, field)
+		}
+	}
+
+	networks := []string{geoIP.Network}
+	for _, o := range matches {
+		result.Networks = append(result.Networks, o.Network.String())
+		networks = append(networks, o.Network.String())
+	}
+
+	// Apply the least specific overlay first, so more specific overlays override it.
+	for _, o := range slices.Backward(matches) {
+		if o.Country != nil {
+			set("country", func() { geoIP.Country = *o.Country })
+		}
+		if o.ISOCountryCode != nil {
+			set("iso_country_code", func() { geoIP.ISOCountryCode = *o.ISOCountryCode })
+		}
+		if o.City != nil {
+			set("city", func() { geoIP.City = *o.City })
+		}
+		if o.Latitude != nil {
+			set("latitude", func() { geoIP.Latitude = *o.Latitude })
+		}
+		if o.Longitude != nil {
+			set("longitude", func() { geoIP.Longitude = *o.Longitude })
+		}
+		if o.ASN != nil {
+			set("asn", func() { geoIP.ASN = *o.ASN })
+		}
+		if o.Organization != nil {
+			set("organization", func() { geoIP.Organization = *o.Organization })
+		}
+		for k, v := range o.Tags {
+			if geoIP.Tags == nil {
+				geoIP.Tags = make(map[string]string)
+			}
+			set("tags", func() { geoIP.Tags[k] = v })
+		}
+	}
+
+	geoIP.Overlay = result
+	geoIP.Network = narrowestNetwork(networks...)
+
+	return geoIP, nil
+}
@@ -0,0 +1,128 @@
+package maxmind_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustOverlay(t *testing.T, cidr string, o maxmind.Overlay) maxmind.Overlay {
+	t.Helper()
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	require.NoError(t, err)
+	o.Network = ipNet
+	return o
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestOverlayProvider_IP2Geo(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	set := maxmind.NewOverlaySet()
+	set.Replace([]maxmind.Overlay{
+		mustOverlay(t, "10.0.0.0/8", maxmind.Overlay{
+			Organization: ptr("Example Corp"),
+			ASN:          ptr(uint(64512)),
+			Tags:         map[string]string{"site": "corp"},
+		}),
+		mustOverlay(t, "10.1.0.0/16", maxmind.Overlay{
+			City:           ptr("Berlin"),
+			ISOCountryCode: ptr("DE"),
+			Tags:           map[string]string{"site": "berlin-office"},
+		}),
+		mustOverlay(t, "216.160.83.0/24", maxmind.Overlay{
+			Organization: ptr("Branch Office"),
+		}),
+		mustOverlay(t, "2001:db8::/32", maxmind.Overlay{
+			Country: ptr("Netherlands"),
+		}),
+	})
+	provider := maxmind.NewOverlayProvider(client, set)
+
+	// The most specific overlay wins, less specific overlays fill in the remaining fields
+	geo, err := provider.IP2Geo("10.1.2.3")
+	require.NoError(t, err)
+	assert.Equal(t, "Berlin", geo.City)
+	assert.Equal(t, "DE", geo.ISOCountryCode)
+	assert.Equal(t, "Example Corp", geo.Organization)
+	assert.Equal(t, uint(64512), geo.ASN)
+	assert.Equal(t, map[string]string{"site": "berlin-office"}, geo.Tags)
+	assert.Equal(t, "10.1.0.0/16", geo.Network)
+	require.NotNil(t, geo.Overlay)
+	assert.Equal(t, []string{"10.1.0.0/16", "10.0.0.0/8"}, geo.Overlay.Networks)
+	assert.ElementsMatch(t, []string{"organization", "asn", "tags", "city", "iso_country_code"}, geo.Overlay.Fields)
+
+	// Overlays are merged on top of MaxMind results
+	geo, err = provider.IP2Geo("216.160.83.56")
+	require.NoError(t, err)
+	assert.Equal(t, "Milton", geo.City)
+	assert.Equal(t, uint(209), geo.ASN)
+	assert.Equal(t, "Branch Office", geo.Organization)
+	assert.Equal(t, "216.160.83.56/29", geo.Network)
+	assert.Equal(t, []string{"organization"}, geo.Overlay.Fields)
+
+	geo, err = provider.IP2Geo("2001:db8::1")
+	require.NoError(t, err)
+	assert.Equal(t, "Netherlands", geo.Country)
+
+	// Addresses without a matching overlay are returned unchanged
+	geo, err = provider.IP2Geo("81.2.69.142")
+	require.NoError(t, err)
+	assert.Equal(t, "London", geo.City)
+	assert.Nil(t, geo.Overlay)
+	assert.Nil(t, geo.Tags)
+
+	// Replacing the set takes effect on the next lookup
+	set.Replace(nil)
+	geo, err = provider.IP2Geo("10.1.2.3")
+	require.NoError(t, err)
+	assert.Nil(t, geo.Overlay)
+}
+
+func TestOverlayProvider_FailedLookups(t *testing.T) {
+	set := maxmind.NewOverlaySet()
+	set.Replace([]maxmind.Overlay{
+		mustOverlay(t, "10.1.0.0/16", maxmind.Overlay{City: ptr("Berlin")}),
+		mustOverlay(t, "216.160.83.0/24", maxmind.Overlay{Organization: ptr("Branch Office")}),
+	})
+
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	// Rejected private addresses are still answered by a matching overlay
+	provider := maxmind.NewOverlayProvider(maxmind.NewSpecialPurposeProvider(client, true), set)
+	geo, err := provider.IP2Geo("10.1.2.3")
+	require.NoError(t, err)
+	assert.Equal(t, "Berlin", geo.City)
+	assert.Equal(t, maxmind.AddressTypePrivate, geo.AddressType)
+	assert.Equal(t, "10.1.0.0/16", geo.Network)
+
+	_, err = provider.IP2Geo("10.2.0.1")
+	require.ErrorIs(t, err, maxmind.ErrNonGlobalIP)
+
+	// So are addresses no database has data for
+	empty := maxmind.NewClient(&config.MaxMindConfig{}, t.TempDir())
+	provider = maxmind.NewOverlayProvider(empty, set)
+	geo, err = provider.IP2Geo("216.160.83.56")
+	require.NoError(t, err)
+	assert.Equal(t, "Branch Office", geo.Organization)
+	assert.Equal(t, "216.160.83.0/24", geo.Network)
+
+	_, err = provider.IP2Geo("81.2.69.142")
+	require.ErrorIs(t, err, maxmind.ErrNoDataAvailable)
+
+	_, err = provider.IP2Geo("not-an-ip")
+	require.ErrorIs(t, err, maxmind.ErrInvalidIP)
+}
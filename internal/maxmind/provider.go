@@ -0,0 +1,167 @@
+package maxmind
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Provider is a source of IP geolocation data.
+type Provider interface {
+	// Name returns the name of the provider.
+	Name() string
+
+	// Load loads the provider databases from disk.
+	Load() error
+
+	// Update refreshes the provider databases and reloads them.
+	Update(ctx context.Context) error
+
+	// Close closes all open database readers.
+	Close()
+
+	// IP2Geo looks up all geographic information for an IP address.
+	IP2Geo(ipStr string, opts ...LookupOption) (GeoIP, error)
+
+	// Metadata returns the metadata of the loaded databases.
+	Metadata() []DBMetadata
+}
+
+var (
+	_ Provider = (*Client)(nil)
+	_ Provider = (*MMDBProvider)(nil)
+)
+
+// DBMetadata describes a loaded database.
+type DBMetadata struct {
+	Name         string        `json:"name"`
+	Path         string        `json:"path"`
+	DatabaseType string        `json:"database_type"`
+	BuildEpoch   time.Time     `json:"build_epoch"`
+	IPVersion    uint          `json:"ip_version"`
+	NodeCount    uint          `json:"node_count"`
+	Languages    []string      `json:"languages"`
+	FileSize     int64         `json:"file_size"`
+	LoadedAt     time.Time     `json:"loaded_at"`
+	LastUpdate   *UpdateStatus `json:"last_update,omitempty"`
+}
+
+func newDBMetadata(name, path string, reader *maxminddb.Reader, loadedAt time.Time) DBMetadata {
+	metadata := DBMetadata{
+		Name:         name,
+		Path:         path,
+		DatabaseType: reader.Metadata.DatabaseType,
+		BuildEpoch:   time.Unix(int64(reader.Metadata.BuildEpoch), 0).UTC(), //nolint:gosec // build epoch is a unix timestamp
+		IPVersion:    reader.Metadata.IPVersion,
+		NodeCount:    reader.Metadata.NodeCount,
+		Languages:    reader.Metadata.Languages,
+		LoadedAt:     loadedAt,
+	}
+	if info, err := os.Stat(path); err == nil {
+		metadata.FileSize = info.Size()
+	}
+	return metadata
+}
+
+// UpdateOutcome is the result of a database update attempt.
+type UpdateOutcome string
+
+const (
+	UpdateOutcomeUpdated   UpdateOutcome = "updated"
+	UpdateOutcomeUnchanged UpdateOutcome = "unchanged"
+	UpdateOutcomeFailed    UpdateOutcome = "failed"
+)
+
+// UpdateStatus describes the last update attempt of a database.
+type UpdateStatus struct {
+	AttemptedAt time.Time     `json:"attempted_at"`
+	Outcome     UpdateOutcome `json:"outcome"`
+	Error       string        `json:"error,omitempty"`
+	CacheValidators
+}
+
+// CacheValidators are the HTTP validators of a downloaded archive, sent with the next download
+// so it can be skipped while the edition is unchanged.
+type CacheValidators struct {
+	LastModified string `json:"last_modified,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+}
+
+// ProviderInfo describes a provider and its loaded databases.
+type ProviderInfo struct {
+	Provider  string       `json:"provider"`
+	Databases []DBMetadata `json:"databases"`
+	Cache     *CacheStats  `json:"cache,omitempty"`
+	// ReverseIndex describes the reverse index, if built.
+	ReverseIndex *ReverseIndexStats `json:"reverse_index,omitempty"`
+}
+
+// NewProviderInfo returns the description of a provider, its loaded databases, its lookup cache
+// and its reverse index, if any.
+func NewProviderInfo(p Provider) ProviderInfo {
+	info := ProviderInfo{
+		Provider:  p.Name(),
+		Databases: p.Metadata(),
+	}
+	if client, ok := AsClient(p); ok {
+		if stats, ok := client.CacheStats(); ok {
+			info.Cache = &stats
+		}
+		if stats, ok := client.ReverseIndexStats(); ok {
+			info.ReverseIndex = &stats
+		}
+	}
+	return info
+}
+
+// AsClient returns the MaxMind client behind a provider, unwrapping providers that decorate it.
+func AsClient(p Provider) (*Client, bool) {
+	for {
+		switch v := p.(type) {
+		case *Client:
+			return v, true
+		case interface{ Unwrap() Provider }:
+			p = v.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// NewProvider creates the provider selected in the configuration. Special-purpose addresses are
+// classified before they reach the provider databases.
+func NewProvider(cfg *config.Config) (Provider, error) {
+	var p Provider
+	switch cfg.Provider.Type {
+	case config.ProviderTypeMaxMind:
+		p = NewClient(&cfg.MaxMind, cfg.Core.DataDir)
+	case config.ProviderTypeMMDB:
+		p = NewMMDBProvider(&cfg.Provider.MMDB, cfg.Core.DataDir)
+	default:
+		return nil, config.ErrProviderTypeInvalid
+	}
+	return NewSpecialPurposeProvider(p, cfg.Lookup.NonGlobal == config.NonGlobalReject), nil
+}
+
+// RunUpdateJob periodically updates the provider databases until the context is done.
+func RunUpdateJob(ctx context.Context, p Provider, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	slog.InfoContext(ctx, "Scheduling DB update job", "provider", p.Name(), "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Stopping DB update job", "provider", p.Name())
+			return
+		case <-ticker.C:
+			if err := p.Update(ctx); err != nil {
+				slog.ErrorContext(ctx, "Background DB update failed", "provider", p.Name(), "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package maxmind
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// languagePlaceholder is replaced with the requested language in record paths.
+const languagePlaceholder = "{lang}"
+
+// mmdbPresets are the built-in field mappings for well known record layouts. DB-IP and
+// IP2Location publish GeoIP2-compatible databases which use the geoip2-* presets.
+var mmdbPresets = map[string]map[string]string{
+	"geoip2-city": {
+		"city":                  "city.names.{lang}",
+		"country":               "country.names.{lang}",
+		"continent":             "continent.names.{lang}",
+		"iso_country_code":      "country.iso_code",
+		"iso_continent_code":    "continent.code",
+		"is_anonymous_proxy":    "traits.is_anonymous_proxy",
+		"is_satellite_provider": "traits.is_satellite_provider",
+		"timezone":              "location.time_zone",
+		"latitude":              "location.latitude",
+		"longitude":             "location.longitude",
+		"accuracy_radius_km":    "location.accuracy_radius",
+		"metro_code":            "location.metro_code",
+		"postal_code":           "postal.code",
+		"subdivision":           "subdivisions.0.names.{lang}",
+		"subdivision_iso_code":  "subdivisions.0.iso_code",
+	},
+	"geoip2-country": {
+		"country":               "country.names.{lang}",
+		"continent":             "continent.names.{lang}",
+		"iso_country_code":      "country.iso_code",
+		"iso_continent_code":    "continent.code",
+		"is_anonymous_proxy":    "traits.is_anonymous_proxy",
+		"is_satellite_provider": "traits.is_satellite_provider",
+	},
+	"geoip2-asn": {
+		"asn":          "autonomous_system_number",
+		"organization": "autonomous_system_organization",
+	},
+	"ipinfo-lite": {
+		"country":            "country",
+		"continent":          "continent",
+		"iso_country_code":   "country_code",
+		"iso_continent_code": "continent_code",
+		"asn":                "asn",
+		"organization":       "as_name",
+	},
+}
+
+// mmdbFieldSetters assign a decoded record value to a lookup field.
+var mmdbFieldSetters = map[string]func(g *GeoIP, v any){
+	"city":                  func(g *GeoIP, v any) { g.City = toString(v) },
+	"country":               func(g *GeoIP, v any) { g.Country = toString(v) },
+	"continent":             func(g *GeoIP, v any) { g.Continent = toString(v) },
+	"iso_country_code":      func(g *GeoIP, v any) { g.ISOCountryCode = toString(v) },
+	"iso_continent_code":    func(g *GeoIP, v any) { g.ISOContinentCode = toString(v) },
+	"is_anonymous_proxy":    func(g *GeoIP, v any) { g.IsAnonymousProxy = toBool(v) },
+	"is_satellite_provider": func(g *GeoIP, v any) { g.IsSatelliteProvider = toBool(v) },
+	"timezone":              func(g *GeoIP, v any) { g.Timezone = toString(v) },
+	"latitude":              func(g *GeoIP, v any) { g.Latitude = toFloat(v) },
+	"longitude":             func(g *GeoIP, v any) { g.Longitude = toFloat(v) },
+	"accuracy_radius_km":    func(g *GeoIP, v any) { g.AccuracyRadius = uint16(toUint(v)) }, //nolint:gosec // accuracy radius fits uint16
+	"metro_code":            func(g *GeoIP, v any) { g.MetroCode = uint(toUint(v)) },
+	"postal_code":           func(g *GeoIP, v any) { g.PostalCode = toString(v) },
+	"subdivision":           func(g *GeoIP, v any) { firstSubdivision(g).Name = toString(v) },
+	"subdivision_iso_code":  func(g *GeoIP, v any) { firstSubdivision(g).ISOCode = toString(v) },
+	"asn":                   func(g *GeoIP, v any) { g.ASN = uint(toUint(v)) },
+	"organization":          func(g *GeoIP, v any) { g.Organization = toString(v) },
+}
+
+type mmdbDatabase struct {
+	name     string
+	path     string
+	fields   map[string]string
+	reader   *maxminddb.Reader
+	loadedAt time.Time
+}
+
+// MMDBProvider serves lookups from generic MaxMind DB (.mmdb) files such as the DB-IP,
+// IPinfo or IP2Location LITE databases, using a per database field mapping.
+type MMDBProvider struct {
+	config    *config.MMDBConfig
+	dataDir   string
+	databases []*mmdbDatabase
+	mu        sync.RWMutex
+}
+
+// NewMMDBProvider creates a new generic mmdb provider.
+func NewMMDBProvider(cfg *config.MMDBConfig, dataDir string) *MMDBProvider {
+	return &MMDBProvider{
+		config:  cfg,
+		dataDir: dataDir,
+	}
+}
+
+// Name returns the name of the provider.
+func (p *MMDBProvider) Name() string {
+	return string(config.ProviderTypeMMDB)
+}
+
+// Load loads all configured databases from disk.
+func (p *MMDBProvider) Load() error {
+	databases := make([]*mmdbDatabase, 0, len(p.config.Databases))
+	closeAll := func() {
+		for _, db := range databases {
+			_ = db.reader.Close()
+		}
+	}
+
+	for _, dbCfg := range p.config.Databases {
+		fields, err := mmdbFieldMapping(dbCfg)
+		if err != nil {
+			closeAll()
+			return err
+		}
+
+		path := filepath.Join(p.dataDir, dbCfg.File)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			slog.Warn("Database file not found, skipping load", "file", dbCfg.File, "path", path)
+			continue
+		}
+
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("%w: file=%s path=%s err=%w", ErrDBOpenFailed, dbCfg.File, path, err)
+		}
+
+		databases = append(databases, &mmdbDatabase{
+			name:     strings.TrimSuffix(filepath.Base(dbCfg.File), filepath.Ext(dbCfg.File)),
+			path:     path,
+			fields:   fields,
+			reader:   reader,
+			loadedAt: time.Now().UTC(),
+		})
+		slog.Info("Loaded database", "file", dbCfg.File, "path", path)
+	}
+
+	p.mu.Lock()
+	old := p.databases
+	p.databases = databases
+	p.mu.Unlock()
+
+	for _, db := range old {
+		_ = db.reader.Close()
+	}
+
+	return nil
+}
+
+// Update reloads the databases from disk. The files are managed outside of Waypoint.
+func (p *MMDBProvider) Update(_ context.Context) error {
+	return p.Load()
+}
+
+// Close closes all open database readers.
+func (p *MMDBProvider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, db := range p.databases {
+		_ = db.reader.Close()
+	}
+	p.databases = nil
+}
+
+// Metadata returns the metadata of the loaded databases.
+func (p *MMDBProvider) Metadata() []DBMetadata {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	metadata := make([]DBMetadata, 0, len(p.databases))
+	for _, db := range p.databases {
+		metadata = append(metadata, newDBMetadata(db.name, db.path, db.reader, db.loadedAt))
+	}
+	return metadata
+}
+
+// IP2Geo looks up all geographic information for an IP address. Databases are applied in
+// configuration order, so a later database overrides fields set by an earlier one. Databases
+// mapping none of the selected fields are skipped, and failing databases are reported as
+// warnings on the result.
+func (p *MMDBProvider) IP2Geo(ipStr string, opts ...LookupOption) (GeoIP, error) {
+	geoIP := GeoIP{
+		IP: ipStr,
+	}
+	o := newLookupOptions(opts...)
+
+	parsedIP := net.ParseIP(ipStr)
+	if parsedIP == nil {
+		return geoIP, ErrInvalidIP
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if len(p.databases) == 0 {
+		return geoIP, ErrMMDBNotLoaded
+	}
+	// The configured databases are not known to describe private networks.
+	if o.specialPurpose {
+		return geoIP, ErrNoDataAvailable
+	}
+
+	geoIP.IPCity.IP = ipStr
+	geoIP.IPASN.IP = ipStr
+	geoIP.Subdivisions = []Subdivision{}
+	geoIP.Networks = make(map[DBType]string, len(p.databases))
+
+	networks := make([]string, 0, len(p.databases))
+	for _, db := range p.databases {
+		if !o.wants(db.outputFields()...) {
+			continue
+		}
+
+		var record any
+		network, _, err := db.reader.LookupNetwork(parsedIP, &record)
+		if err != nil {
+			geoIP.warn(db.name, err)
+			continue
+		}
+		geoIP.Networks[DBType(db.name)] = network.String()
+		networks = append(networks, network.String())
+
+		for field, path := range db.fields {
+			if v, ok := recordValue(record, path, o.language); ok {
+				mmdbFieldSetters[field](&geoIP, v)
+			}
+		}
+	}
+	if len(networks) == 0 && len(geoIP.Warnings) > 0 {
+		return geoIP, noDataError(geoIP.Warnings)
+	}
+	geoIP.Network = narrowestNetwork(networks...)
+
+	return geoIP, nil
+}
+
+// outputFields returns the lookup result fields the database sets.
+func (db *mmdbDatabase) outputFields() []string {
+	fields := make([]string, 0, len(db.fields))
+	for field := range db.fields {
+		switch field {
+		case "subdivision", "subdivision_iso_code":
+			fields = append(fields, "subdivisions")
+		default:
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// mmdbFieldMapping merges the preset and the explicit field mapping of a database.
+// An empty path removes a field of the preset.
+func mmdbFieldMapping(dbCfg config.MMDBDatabaseConfig) (map[string]string, error) {
+	fields := make(map[string]string)
+	if dbCfg.Preset != "" {
+		preset, ok := mmdbPresets[dbCfg.Preset]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMMDBUnknownPreset, dbCfg.Preset)
+		}
+		maps.Copy(fields, preset)
+	}
+
+	for field, path := range dbCfg.Fields {
+		if _, ok := mmdbFieldSetters[field]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMMDBUnknownField, field)
+		}
+		if path == "" {
+			delete(fields, field)
+			continue
+		}
+		fields[field] = path
+	}
+
+	return fields, nil
+}
+
+// recordValue resolves a dotted path such as "city.names.{lang}" or "subdivisions.0.iso_code"
+// in a decoded record. Localized paths fall back to English.
+func recordValue(record any, path, lang string) (any, bool) {
+	if strings.Contains(path, languagePlaceholder) {
+		if v, ok := resolvePath(record, strings.ReplaceAll(path, languagePlaceholder, lang)); ok {
+			return v, true
+		}
+		path = strings.ReplaceAll(path, languagePlaceholder, DefaultLanguage)
+	}
+	return resolvePath(record, path)
+}
+
+func resolvePath(record any, path string) (any, bool) {
+	current := record
+	for part := range strings.SplitSeq(path, ".") {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []any:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			current = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+func firstSubdivision(g *GeoIP) *Subdivision {
+	if len(g.Subdivisions) == 0 {
+		g.Subdivisions = append(g.Subdivisions, Subdivision{})
+	}
+	return &g.Subdivisions[0]
+}
+
+func toString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	default:
+		return float64(toUint(v))
+	}
+}
+
+// toUint converts numeric record values. Strings such as "AS13335" are accepted for ASNs.
+func toUint(v any) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case uint32:
+		return uint64(n)
+	case uint16:
+		return uint64(n)
+	case int:
+		return uint64(max(n, 0))
+	case float64:
+		return uint64(max(n, 0))
+	case string:
+		u, _ := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(n), "AS"), 10, 64)
+		return u
+	default:
+		return 0
+	}
+}
+
+func toBool(v any) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		parsed, _ := strconv.ParseBool(b)
+		return parsed
+	default:
+		return toUint(v) != 0
+	}
+}
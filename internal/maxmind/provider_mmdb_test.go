@@ -0,0 +1,102 @@
+package maxmind_test
+
+import (
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMMDBProvider_Presets(t *testing.T) {
+	cfg := &config.MMDBConfig{
+		Databases: []config.MMDBDatabaseConfig{
+			{File: "GeoLite2-City.mmdb", Preset: "geoip2-city"},
+			{File: "GeoLite2-ASN.mmdb", Preset: "geoip2-asn"},
+		},
+	}
+
+	provider := maxmind.NewMMDBProvider(cfg, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, provider.Load())
+	defer provider.Close()
+
+	// 89.160.20.112 is Linköping, SE (AS29518) in the MaxMind test databases
+	geo, err := provider.IP2Geo("89.160.20.112")
+	require.NoError(t, err)
+	assert.Equal(t, "Linköping", geo.City)
+	assert.Equal(t, "Sweden", geo.Country)
+	assert.Equal(t, "SE", geo.ISOCountryCode)
+	assert.Equal(t, "EU", geo.ISOContinentCode)
+	assert.Equal(t, "Europe/Stockholm", geo.Timezone)
+	assert.InDelta(t, 58.4167, geo.Latitude, 0.0001)
+	assert.Equal(t, uint16(76), geo.AccuracyRadius)
+	assert.Equal(t, []maxmind.Subdivision{{ISOCode: "E", Name: "Östergötland County"}}, geo.Subdivisions)
+	assert.Equal(t, uint(29518), geo.ASN)
+	assert.Equal(t, "89.160.20.112/28", geo.Network)
+	assert.Equal(t, "89.160.0.0/17", geo.Networks["GeoLite2-ASN"])
+
+	geo, err = provider.IP2Geo("89.160.20.112", maxmind.WithLanguage("zh-CN"))
+	require.NoError(t, err)
+	assert.Equal(t, "林雪平", geo.City)
+	assert.Equal(t, "Östergötland County", geo.Subdivisions[0].Name)
+
+	metadata := provider.Metadata()
+	require.Len(t, metadata, 2)
+	assert.Equal(t, "GeoLite2-City", metadata[0].Name)
+	assert.Equal(t, "GeoIP2-City", metadata[0].DatabaseType)
+}
+
+func TestMMDBProvider_FieldMapping(t *testing.T) {
+	cfg := &config.MMDBConfig{
+		Databases: []config.MMDBDatabaseConfig{
+			{
+				File:   "GeoLite2-City.mmdb",
+				Preset: "geoip2-country",
+				Fields: map[string]string{
+					"country":  "", // drop a preset field
+					"city":     "city.names.{lang}",
+					"timezone": "location.time_zone",
+				},
+			},
+		},
+	}
+
+	provider := maxmind.NewMMDBProvider(cfg, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, provider.Load())
+	defer provider.Close()
+
+	geo, err := provider.IP2Geo("81.2.69.142")
+	require.NoError(t, err)
+	assert.Equal(t, "London", geo.City)
+	assert.Empty(t, geo.Country)
+	assert.Equal(t, "GB", geo.ISOCountryCode)
+	assert.Equal(t, "Europe/London", geo.Timezone)
+	assert.Zero(t, geo.Latitude)
+}
+
+func TestMMDBProvider_Errors(t *testing.T) {
+	dataDir := testhelpers.SetupTestDBDir(t)
+
+	provider := maxmind.NewMMDBProvider(&config.MMDBConfig{
+		Databases: []config.MMDBDatabaseConfig{{File: "GeoLite2-City.mmdb", Preset: "unknown"}},
+	}, dataDir)
+	require.ErrorIs(t, provider.Load(), maxmind.ErrMMDBUnknownPreset)
+
+	provider = maxmind.NewMMDBProvider(&config.MMDBConfig{
+		Databases: []config.MMDBDatabaseConfig{{File: "GeoLite2-City.mmdb", Fields: map[string]string{"unknown": "x"}}},
+	}, dataDir)
+	require.ErrorIs(t, provider.Load(), maxmind.ErrMMDBUnknownField)
+
+	provider = maxmind.NewMMDBProvider(&config.MMDBConfig{
+		Databases: []config.MMDBDatabaseConfig{{File: "missing.mmdb", Preset: "geoip2-city"}},
+	}, dataDir)
+	require.NoError(t, provider.Load())
+
+	_, err := provider.IP2Geo("81.2.69.142")
+	require.ErrorIs(t, err, maxmind.ErrMMDBNotLoaded)
+
+	_, err = provider.IP2Geo("invalid")
+	require.ErrorIs(t, err, maxmind.ErrInvalidIP)
+}
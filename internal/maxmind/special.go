@@ -0,0 +1,239 @@
+package maxmind
+
+import (
+	"cmp"
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// AddressType classifies an IP address by the special-purpose block it belongs to.
+type AddressType string
+
+const (
+	AddressTypeGlobal        AddressType = "global"
+	AddressTypePrivate       AddressType = "private"
+	AddressTypeShared        AddressType = "shared"
+	AddressTypeLoopback      AddressType = "loopback"
+	AddressTypeLinkLocal     AddressType = "link_local"
+	AddressTypeDocumentation AddressType = "documentation"
+	AddressTypeBenchmarking  AddressType = "benchmarking"
+	AddressTypeMulticast     AddressType = "multicast"
+	AddressTypeBroadcast     AddressType = "broadcast"
+	AddressTypeUnspecified   AddressType = "unspecified"
+	AddressTypeReserved      AddressType = "reserved"
+)
+
+// addressTypesByName maps the names of the IANA special-purpose registries to address types.
+// Blocks with other names are reserved.
+var addressTypesByName = map[string]AddressType{
+	`"This network"`:              AddressTypeUnspecified,
+	`"This host on this network"`: AddressTypeUnspecified,
+	"Unspecified Address":         AddressTypeUnspecified,
+	"Private-Use":                 AddressTypePrivate,
+	"Unique-Local":                AddressTypePrivate,
+	"Shared Address Space":        AddressTypeShared,
+	"Loopback":                    AddressTypeLoopback,
+	"Loopback Address":            AddressTypeLoopback,
+	"Link Local":                  AddressTypeLinkLocal,
+	"Link-Local Unicast":          AddressTypeLinkLocal,
+	"Benchmarking":                AddressTypeBenchmarking,
+	"Limited Broadcast":           AddressTypeBroadcast,
+}
+
+// multicastBlocks are allocated in the IANA address space registries rather than the
+// special-purpose registries.
+var multicastBlocks = []SpecialPurposeBlock{
+	{Network: mustParseCIDR("224.0.0.0/4"), Name: "Multicast", RFC: "RFC 5771", AddressType: AddressTypeMulticast},
+	{Network: mustParseCIDR("ff00::/8"), Name: "Multicast", RFC: "RFC 4291", AddressType: AddressTypeMulticast},
+}
+
+//go:embed registry/*.csv
+var registryFS embed.FS
+
+var rfcPattern = regexp.MustCompile(`RFC(\d+)`)
+
+// SpecialPurposeBlock is an entry of the IANA IPv4 and IPv6 special-purpose address registries.
+type SpecialPurposeBlock struct {
+	Network           *net.IPNet
+	Name              string
+	RFC               string
+	AddressType       AddressType
+	GloballyReachable bool
+}
+
+// Remark returns a human-readable description of the block.
+func (b SpecialPurposeBlock) Remark() string {
+	name := strings.Trim(b.Name, `"`)
+	if b.RFC == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, b.RFC)
+}
+
+var specialPurposeBlocks = sync.OnceValue(func() []SpecialPurposeBlock {
+	var blocks []SpecialPurposeBlock
+	for _, name := range []string{"registry/iana-ipv4-special-registry.csv", "registry/iana-ipv6-special-registry.csv"} {
+		parsed, err := parseSpecialPurposeRegistry(name)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded registry %s: %v", name, err))
+		}
+		blocks = append(blocks, parsed...)
+	}
+	blocks = append(blocks, multicastBlocks...)
+
+	// Most specific blocks first, so the first match is the longest prefix match.
+	slices.SortStableFunc(blocks, func(a, b SpecialPurposeBlock) int {
+		aOnes, _ := a.Network.Mask.Size()
+		bOnes, _ := b.Network.Mask.Size()
+		return cmp.Compare(bOnes, aOnes)
+	})
+	return blocks
+})
+
+// parseSpecialPurposeRegistry parses a special-purpose registry in the CSV format published by IANA.
+func parseSpecialPurposeRegistry(name string) ([]SpecialPurposeBlock, error) {
+	f, err := registryFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	const (
+		colAddressBlock      = 0
+		colName              = 1
+		colRFC               = 2
+		colGloballyReachable = 8
+	)
+
+	var blocks []SpecialPurposeBlock
+	for _, record := range records[1:] {
+		name := record[colName]
+		addressType, ok := addressTypesByName[name]
+		if !ok {
+			addressType = AddressTypeReserved
+		}
+		if strings.HasPrefix(name, "Documentation") {
+			addressType = AddressTypeDocumentation
+		}
+
+		var rfcs []string
+		for _, m := range rfcPattern.FindAllStringSubmatch(record[colRFC], -1) {
+			rfcs = append(rfcs, "RFC "+m[1])
+		}
+
+		// Blocks that are not applicable, like 6to4 and Teredo, are routed like global addresses.
+		reachable := strings.TrimSpace(record[colGloballyReachable])
+		globallyReachable := strings.HasPrefix(reachable, "True") || strings.HasPrefix(reachable, "N/A")
+
+		for _, cidr := range strings.Split(record[colAddressBlock], ",") {
+			_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, SpecialPurposeBlock{
+				Network:           ipNet,
+				Name:              name,
+				RFC:               strings.Join(rfcs, ", "),
+				AddressType:       addressType,
+				GloballyReachable: globallyReachable,
+			})
+		}
+	}
+	return blocks, nil
+}
+
+// ClassifyIP returns the most specific special-purpose block containing ip. It reports false if
+// the address is not in a special-purpose block or the block is globally reachable.
+func ClassifyIP(ip net.IP) (SpecialPurposeBlock, bool) {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, block := range specialPurposeBlocks() {
+		if len(block.Network.IP) != len(ip) || !block.Network.Contains(ip) {
+			continue
+		}
+		if block.GloballyReachable {
+			return SpecialPurposeBlock{}, false
+		}
+		return block, true
+	}
+	return SpecialPurposeBlock{}, false
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
+
+// SpecialPurposeProvider answers lookups of special-purpose addresses, such as private, loopback
+// or documentation addresses, from the IANA registries instead of the databases of the wrapped
+// provider, and optionally rejects them.
+type SpecialPurposeProvider struct {
+	Provider
+	reject bool
+}
+
+// NewSpecialPurposeProvider wraps a provider. If reject is set, lookups of addresses that are not
+// globally reachable fail with ErrNonGlobalIP.
+func NewSpecialPurposeProvider(p Provider, reject bool) *SpecialPurposeProvider {
+	return &SpecialPurposeProvider{
+		Provider: p,
+		reject:   reject,
+	}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *SpecialPurposeProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// IP2Geo classifies an IP address and looks up globally reachable addresses in the wrapped provider.
+func (p *SpecialPurposeProvider) IP2Geo(ipStr string, opts ...LookupOption) (GeoIP, error) {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return GeoIP{IP: ipStr}, ErrInvalidIP
+	}
+
+	block, ok := ClassifyIP(ip)
+	if !ok {
+		geoIP, err := p.Provider.IP2Geo(ipStr, opts...)
+		geoIP.AddressType = AddressTypeGlobal
+		return geoIP, err
+	}
+
+	// Custom editions usually describe private networks, so the wrapped provider still answers
+	// from them, even when these addresses are rejected otherwise.
+	geoIP, err := p.Provider.IP2Geo(ipStr, append(opts, withSpecialPurpose())...)
+	if err == nil {
+		geoIP.AddressType = block.AddressType
+		geoIP.Remark = block.Remark()
+		geoIP.Network = narrowestNetwork(geoIP.Network, block.Network.String())
+		return geoIP, nil
+	}
+
+	geoIP = GeoIP{
+		IP:          ipStr,
+		AddressType: block.AddressType,
+		Remark:      block.Remark(),
+		Warnings:    geoIP.Warnings,
+	}
+	geoIP.Network = block.Network.String()
+	if p.reject {
+		return geoIP, fmt.Errorf("%w: %s is %s", ErrNonGlobalIP, ipStr, geoIP.Remark)
+	}
+	return geoIP, nil
+}
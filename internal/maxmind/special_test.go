@@ -0,0 +1,127 @@
+package maxmind_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/maxmind"
+	"github.com/hibare/Waypoint/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyIP(t *testing.T) {
+	tests := []struct {
+		ip          string
+		addressType maxmind.AddressType
+		network     string
+		remark      string
+	}{
+		{"10.1.2.3", maxmind.AddressTypePrivate, "10.0.0.0/8", "Private-Use (RFC 1918)"},
+		{"192.168.1.1", maxmind.AddressTypePrivate, "192.168.0.0/16", "Private-Use (RFC 1918)"},
+		{"100.64.0.1", maxmind.AddressTypeShared, "100.64.0.0/10", "Shared Address Space (RFC 6598)"},
+		{"127.0.0.1", maxmind.AddressTypeLoopback, "127.0.0.0/8", "Loopback (RFC 1122)"},
+		{"169.254.169.254", maxmind.AddressTypeLinkLocal, "169.254.0.0/16", "Link Local (RFC 3927)"},
+		{"198.51.100.7", maxmind.AddressTypeDocumentation, "198.51.100.0/24", "Documentation (TEST-NET-2) (RFC 5737)"},
+		{"0.0.0.0", maxmind.AddressTypeUnspecified, "0.0.0.0/32", "This host on this network (RFC 1122)"},
+		{"239.255.255.250", maxmind.AddressTypeMulticast, "224.0.0.0/4", "Multicast (RFC 5771)"},
+		{"255.255.255.255", maxmind.AddressTypeBroadcast, "255.255.255.255/32", "Limited Broadcast (RFC 8190, RFC 919)"},
+		{"250.1.2.3", maxmind.AddressTypeReserved, "240.0.0.0/4", "Reserved (RFC 1112)"},
+		{"::1", maxmind.AddressTypeLoopback, "::1/128", "Loopback Address (RFC 4291)"},
+		{"::ffff:10.0.0.1", maxmind.AddressTypePrivate, "10.0.0.0/8", "Private-Use (RFC 1918)"},
+		{"fd12:3456::1", maxmind.AddressTypePrivate, "fc00::/7", "Unique-Local (RFC 4193, RFC 8190)"},
+		{"fe80::1", maxmind.AddressTypeLinkLocal, "fe80::/10", "Link-Local Unicast (RFC 4291)"},
+		{"2001:db8::1", maxmind.AddressTypeDocumentation, "2001:db8::/32", "Documentation (RFC 3849)"},
+		{"ff02::1", maxmind.AddressTypeMulticast, "ff00::/8", "Multicast (RFC 4291)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			block, ok := maxmind.ClassifyIP(net.ParseIP(tt.ip))
+			require.True(t, ok)
+			assert.Equal(t, tt.addressType, block.AddressType)
+			assert.Equal(t, tt.network, block.Network.String())
+			assert.Equal(t, tt.remark, block.Remark())
+		})
+	}
+
+	// Global addresses and globally reachable special-purpose blocks are not classified
+	for _, ip := range []string{"8.8.8.8", "192.0.0.9", "192.31.196.1", "2606:4700::1111", "2001:1::1", "2002:c000:204::1"} {
+		_, ok := maxmind.ClassifyIP(net.ParseIP(ip))
+		assert.False(t, ok, ip)
+	}
+}
+
+func TestSpecialPurposeProvider_IP2Geo(t *testing.T) {
+	client := maxmind.NewClient(&config.MaxMindConfig{}, testhelpers.SetupTestDBDir(t))
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	provider := maxmind.NewSpecialPurposeProvider(client, false)
+
+	geo, err := provider.IP2Geo("100.64.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, maxmind.AddressTypeShared, geo.AddressType)
+	assert.Equal(t, "Shared Address Space (RFC 6598)", geo.Remark)
+	assert.Equal(t, "100.64.0.0/10", geo.Network)
+	assert.Empty(t, geo.Networks)
+
+	geo, err = provider.IP2Geo("81.2.69.142")
+	require.NoError(t, err)
+	assert.Equal(t, maxmind.AddressTypeGlobal, geo.AddressType)
+	assert.Equal(t, "London", geo.City)
+	assert.Empty(t, geo.Remark)
+
+	_, err = provider.IP2Geo("not-an-ip")
+	require.ErrorIs(t, err, maxmind.ErrInvalidIP)
+
+	provider = maxmind.NewSpecialPurposeProvider(client, true)
+	_, err = provider.IP2Geo("192.168.1.1")
+	require.ErrorIs(t, err, maxmind.ErrNonGlobalIP)
+
+	geo, err = provider.IP2Geo("81.2.69.142")
+	require.NoError(t, err)
+	assert.Equal(t, "London", geo.City)
+}
+
+func TestSpecialPurposeProvider_CustomEditions(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+	_, network, err := net.ParseCIDR("10.1.0.0/16")
+	require.NoError(t, err)
+
+	dataDir := testhelpers.SetupTestDBDir(t)
+	f, err := os.Create(filepath.Join(dataDir, "corp.mmdb"))
+	require.NoError(t, err)
+	_, err = maxmind.BuildMMDB(f, []maxmind.Overlay{{Network: network, City: ptr("Berlin")}}, maxmind.BuildOptions{})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	client := maxmind.NewClient(&config.MaxMindConfig{CustomEditions: []string{"corp"}}, dataDir)
+	require.NoError(t, client.Load())
+	defer client.Close()
+
+	for _, reject := range []bool{false, true} {
+		provider := maxmind.NewSpecialPurposeProvider(client, reject)
+
+		// Custom editions answer private addresses, even when they are rejected otherwise
+		geo, err := provider.IP2Geo("10.1.2.3")
+		require.NoError(t, err)
+		assert.Equal(t, "Berlin", geo.City)
+		assert.Equal(t, maxmind.AddressTypePrivate, geo.AddressType)
+		assert.Equal(t, "Private-Use (RFC 1918)", geo.Remark)
+		assert.Equal(t, "10.1.0.0/16", geo.Network)
+		assert.Equal(t, map[maxmind.DBType]string{"corp": "10.1.0.0/16"}, geo.Networks)
+
+		geo, err = provider.IP2Geo("10.2.0.1")
+		if reject {
+			require.ErrorIs(t, err, maxmind.ErrNonGlobalIP)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.0/8", geo.Network)
+		assert.Empty(t, geo.City)
+		assert.Empty(t, geo.Networks)
+	}
+}
@@ -1,5 +1,10 @@
 package maxmind
 
+import (
+	"fmt"
+	"strings"
+)
+
 // DBType represents the type of MaxMind database.
 type DBType string
 
@@ -7,11 +12,32 @@ const (
 	DBTypeCountry DBType = "GeoLite2-Country"
 	DBTypeCity    DBType = "GeoLite2-City"
 	DBTypeASN     DBType = "GeoLite2-ASN"
+
+	// GeoIP2 commercial editions.
+	DBTypeGeoIP2Country  DBType = "GeoIP2-Country"
+	DBTypeGeoIP2City     DBType = "GeoIP2-City"
+	DBTypeISP            DBType = "GeoIP2-ISP"
+	DBTypeConnectionType DBType = "GeoIP2-Connection-Type"
+	DBTypeAnonymousIP    DBType = "GeoIP2-Anonymous-IP"
+	DBTypeDomain         DBType = "GeoIP2-Domain"
+	DBTypeEnterprise     DBType = "GeoIP2-Enterprise"
+)
+
+var (
+	// countryDBTypes are the editions that can answer country lookups, in order of preference.
+	countryDBTypes = []DBType{DBTypeGeoIP2Country, DBTypeCountry}
+
+	// cityDBTypes are the editions that can answer city lookups, in order of preference.
+	cityDBTypes = []DBType{DBTypeEnterprise, DBTypeGeoIP2City, DBTypeCity}
+
+	// asnDBTypes are the editions that can answer ASN lookups, in order of preference.
+	asnDBTypes = []DBType{DBTypeISP, DBTypeASN}
 )
 
 // IPCountry represents country information for an IP.
 type IPCountry struct {
 	IP                  string `json:"ip"`
+	Network             string `json:"network"`
 	Country             string `json:"country"`
 	Continent           string `json:"continent"`
 	ISOCountryCode      string `json:"iso_country_code"`
@@ -24,22 +50,92 @@ type IPCountry struct {
 type IPCity struct {
 	City string `json:"city"`
 	IPCountry
-	Timezone  string  `json:"timezone"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	Timezone       string        `json:"timezone"`
+	Latitude       float64       `json:"latitude"`
+	Longitude      float64       `json:"longitude"`
+	AccuracyRadius uint16        `json:"accuracy_radius_km"`
+	PostalCode     string        `json:"postal_code"`
+	Subdivisions   []Subdivision `json:"subdivisions"`
+	MetroCode      uint          `json:"metro_code"`
+}
+
+// Subdivision represents a country subdivision (state, province, county) an IP is located in.
+type Subdivision struct {
+	ISOCode string `json:"iso_code"`
+	Name    string `json:"name"`
 }
 
 // IPASN represents ASN information for an IP.
 type IPASN struct {
 	IP           string `json:"ip"`
+	Network      string `json:"network"`
 	ASN          uint   `json:"asn"`
 	Organization string `json:"organization"`
 }
 
+// IPTraits represents network traits of an IP from the GeoIP2 commercial editions.
+// Fields are only set when an edition that provides them is loaded.
+type IPTraits struct {
+	ISP            string      `json:"isp,omitempty"`
+	ConnectionType string      `json:"connection_type,omitempty"`
+	IsVPN          *bool       `json:"is_vpn,omitempty"`
+	IsTor          *bool       `json:"is_tor,omitempty"`
+	IsHosting      *bool       `json:"is_hosting,omitempty"`
+	Domain         string      `json:"domain,omitempty"`
+	UserType       string      `json:"user_type,omitempty"`
+	Confidence     *Confidence `json:"confidence,omitempty"`
+}
+
+// Confidence holds the GeoIP2 Enterprise confidence (0-100) that the location fields are correct.
+type Confidence struct {
+	Country     uint8 `json:"country"`
+	Subdivision uint8 `json:"subdivision"`
+	City        uint8 `json:"city"`
+	PostalCode  uint8 `json:"postal_code"`
+}
+
 // GeoIP represents complete geographic and ASN information for an IP.
 type GeoIP struct {
 	IPCity
 	IPASN
-	IP     string `json:"ip"`
+	IPTraits
+	IP string `json:"ip"`
+	// Network is the narrowest of the matched networks, the block of addresses that share this result.
+	Network string `json:"network"`
+	// Networks holds the network matched in each database.
+	Networks map[DBType]string `json:"networks"`
+	// Warnings lists the databases that could not contribute to the result.
+	Warnings []Warning `json:"warnings,omitempty"`
+	// Tags are the tags of the overlays and custom editions matching the IP.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Overlay describes the overlays merged into the result.
+	Overlay *OverlayResult `json:"overlay,omitempty"`
+	// AddressType classifies the IP by the IANA special-purpose block it belongs to.
+	AddressType AddressType `json:"address_type,omitempty"`
+	// Remark describes the special-purpose block of the IP.
 	Remark string `json:"remark,omitempty"`
+	// PTR is the reverse DNS name of the IP, only set when requested.
+	PTR string `json:"ptr,omitempty"`
+}
+
+// Warning describes a database that is missing or failed during a lookup, so the fields it
+// answers are missing from the result.
+type Warning struct {
+	// Source is the edition, or the kind of database (city, country, asn) if none is loaded.
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// warn records a database that could not contribute to the result.
+func (g *GeoIP) warn(source string, err error) {
+	g.Warnings = append(g.Warnings, Warning{Source: source, Error: err.Error()})
+}
+
+// noDataError returns the error of a lookup no database could answer.
+func noDataError(warnings []Warning) error {
+	reasons := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", w.Source, w.Error))
+	}
+	return fmt.Errorf("%w: %s", ErrNoDataAvailable, strings.Join(reasons, "; "))
 }
@@ -0,0 +1,151 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// negativePTRTTL is how long an address without PTR records is cached.
+	negativePTRTTL = 5 * time.Minute
+
+	// maxPTRTTL caps how long a PTR answer is cached, whatever its TTL.
+	maxPTRTTL = time.Hour
+
+	hexDigits = "0123456789abcdef"
+)
+
+// ErrPTRNotFound is returned when an address has no PTR records.
+var ErrPTRNotFound = errors.New("no PTR records found")
+
+// LookupPTR returns the PTR names of an IP address, without their trailing dot. Answers are
+// cached until their TTL expires.
+func (r *Resolver) LookupPTR(ctx context.Context, ip net.IP) ([]string, error) {
+	key := ip.String()
+	if names, ok := r.ptrCache.get(key); ok {
+		if len(names) == 0 {
+			return nil, ErrPTRNotFound
+		}
+		return names, nil
+	}
+
+	name, err := dnsmessage.NewName(reverseName(ip))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.ptrTimeout)
+	defer cancel()
+
+	msg, err := r.exchange(ctx, name, dnsmessage.TypePTR)
+	if err != nil {
+		return nil, err
+	}
+
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrResolveFailed, msg.RCode)
+	}
+
+	var names []string
+	ttl := maxPTRTTL
+	for _, answer := range msg.Answers {
+		if body, ok := answer.Body.(*dnsmessage.PTRResource); ok {
+			names = append(names, strings.TrimSuffix(body.PTR.String(), "."))
+			ttl = min(ttl, time.Duration(answer.Header.TTL)*time.Second)
+		}
+	}
+
+	if len(names) == 0 {
+		r.ptrCache.set(key, nil, negativePTRTTL)
+		return nil, ErrPTRNotFound
+	}
+	r.ptrCache.set(key, names, ttl)
+	return names, nil
+}
+
+// reverseName returns the in-addr.arpa or ip6.arpa name of an IP address.
+func reverseName(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", ip4[3], ip4[2], ip4[1], ip4[0])
+	}
+
+	var b strings.Builder
+	ip16 := ip.To16()
+	for i := len(ip16) - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[ip16[i]&0x0f])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[ip16[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString("ip6.arpa.")
+	return b.String()
+}
+
+type ptrCacheEntry struct {
+	names   []string
+	expires time.Time
+}
+
+// ptrCache holds reverse lookup results until their TTL expires. When full, expired entries are
+// dropped first, then arbitrary ones.
+type ptrCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]ptrCacheEntry
+}
+
+func newPTRCache(size int) *ptrCache {
+	return &ptrCache{
+		size:    size,
+		entries: make(map[string]ptrCacheEntry),
+	}
+}
+
+func (c *ptrCache) get(key string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.names, true
+}
+
+func (c *ptrCache) set(key string, names []string, ttl time.Duration) {
+	if c.size == 0 || ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		now := time.Now()
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < c.size {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = ptrCacheEntry{names: names, expires: time.Now().Add(ttl)}
+}
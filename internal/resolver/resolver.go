@@ -0,0 +1,313 @@
+// Package resolver resolves hostnames with a minimal DNS client that, unlike the resolver of the
+// standard library, reports the TTL and the CNAME chain of the answers.
+package resolver
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	resolvConfPath = "/etc/resolv.conf"
+	dnsPort        = "53"
+	maxUDPSize     = 1232
+	tcpLengthSize  = 2
+	maxNameLength  = 253
+	maxLabelLength = 63
+
+	// maxCNAMEChain limits the CNAME hops followed for a name.
+	maxCNAMEChain = 8
+)
+
+var (
+	// ErrInvalidHostname is returned when the name to resolve is not a valid hostname.
+	ErrInvalidHostname = errors.New("invalid hostname")
+
+	// ErrHostNotFound is returned when the name does not exist or has no addresses.
+	ErrHostNotFound = errors.New("hostname not found")
+
+	// ErrResolveFailed is returned when no DNS server answered the query.
+	ErrResolveFailed = errors.New("failed to resolve hostname")
+
+	// ErrCNAMEChainTooLong is returned when a name has more CNAME hops than are followed.
+	ErrCNAMEChainTooLong = errors.New("CNAME chain too long")
+)
+
+// defaultServers are queried when no nameserver is configured, like the standard library does.
+var defaultServers = []string{"127.0.0.1:53", "[::1]:53"}
+
+// Record is an address record of a resolved name.
+type Record struct {
+	IP   net.IP `json:"ip"`
+	Type string `json:"type"`
+	TTL  uint32 `json:"ttl"`
+}
+
+// Resolution is the result of resolving a name.
+type Resolution struct {
+	// Name is the resolved name in canonical form.
+	Name string `json:"name"`
+	// CNAMEs are the aliases followed from Name to the name holding the records, in order.
+	CNAMEs []string `json:"cname_chain"`
+	// Records are the A and AAAA records, at most the configured maximum.
+	Records []Record `json:"records"`
+	// Truncated is set if the name has more records than the configured maximum.
+	Truncated bool `json:"truncated"`
+}
+
+// Resolver resolves hostnames to their A and AAAA records, and addresses to their PTR names.
+type Resolver struct {
+	servers    []string
+	timeout    time.Duration
+	maxRecords int
+	ptrTimeout time.Duration
+	ptrCache   *ptrCache
+}
+
+// New creates a resolver querying the configured DNS server, or the nameservers of the system.
+func New(cfg *config.ResolverConfig) *Resolver {
+	servers := []string{cfg.Server}
+	if cfg.Server == "" {
+		servers = systemServers(resolvConfPath)
+	}
+
+	return &Resolver{
+		servers:    servers,
+		timeout:    cfg.Timeout,
+		maxRecords: cfg.MaxRecords,
+		ptrTimeout: cfg.PTRTimeout,
+		ptrCache:   newPTRCache(cfg.PTRCacheSize),
+	}
+}
+
+// systemServers returns the nameservers listed in a resolv.conf file.
+func systemServers(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return defaultServers
+	}
+	defer func() { _ = f.Close() }()
+
+	var servers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" && net.ParseIP(fields[1]) != nil {
+			servers = append(servers, net.JoinHostPort(fields[1], dnsPort))
+		}
+	}
+	if len(servers) == 0 {
+		return defaultServers
+	}
+	return servers
+}
+
+// LookupHost resolves a hostname to its A and AAAA records, following CNAMEs.
+func (r *Resolver) LookupHost(ctx context.Context, host string) (*Resolution, error) {
+	name, err := dnsmessage.NewName(canonicalName(host))
+	if err != nil || !isHostname(host) {
+		return nil, ErrInvalidHostname
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	resolution := &Resolution{Name: name.String(), CNAMEs: []string{}, Records: []Record{}}
+	var found bool
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		records, cnames, err := r.lookup(ctx, name, qtype)
+		if errors.Is(err, ErrHostNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		if len(cnames) > len(resolution.CNAMEs) {
+			resolution.CNAMEs = cnames
+		}
+		for _, record := range records {
+			if len(resolution.Records) == r.maxRecords {
+				resolution.Truncated = true
+				break
+			}
+			resolution.Records = append(resolution.Records, record)
+		}
+	}
+
+	if !found || len(resolution.Records) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotFound, host)
+	}
+	return resolution, nil
+}
+
+// lookup queries the records of a type, following the CNAME chain of the answer.
+func (r *Resolver) lookup(ctx context.Context, name dnsmessage.Name, qtype dnsmessage.Type) ([]Record, []string, error) {
+	msg, err := r.exchange(ctx, name, qtype)
+	if err != nil {
+		return nil, nil, err
+	}
+	if msg.RCode == dnsmessage.RCodeNameError {
+		return nil, nil, ErrHostNotFound
+	}
+	if msg.RCode != dnsmessage.RCodeSuccess {
+		return nil, nil, fmt.Errorf("%w: %s", ErrResolveFailed, msg.RCode)
+	}
+
+	var cnames []string
+	current := name
+	for range maxCNAMEChain + 1 {
+		next, ok := cnameTarget(msg.Answers, current)
+		if !ok {
+			break
+		}
+		if len(cnames) == maxCNAMEChain {
+			return nil, nil, ErrCNAMEChainTooLong
+		}
+		cnames = append(cnames, next.String())
+		current = next
+	}
+
+	var records []Record
+	for _, answer := range msg.Answers {
+		if !strings.EqualFold(answer.Header.Name.String(), current.String()) {
+			continue
+		}
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			records = append(records, Record{IP: net.IP(body.A[:]), Type: "A", TTL: answer.Header.TTL})
+		case *dnsmessage.AAAAResource:
+			records = append(records, Record{IP: net.IP(body.AAAA[:]), Type: "AAAA", TTL: answer.Header.TTL})
+		}
+	}
+	return records, cnames, nil
+}
+
+func cnameTarget(answers []dnsmessage.Resource, name dnsmessage.Name) (dnsmessage.Name, bool) {
+	for _, answer := range answers {
+		if body, ok := answer.Body.(*dnsmessage.CNAMEResource); ok &&
+			strings.EqualFold(answer.Header.Name.String(), name.String()) {
+			return body.CNAME, true
+		}
+	}
+	return dnsmessage.Name{}, false
+}
+
+// exchange sends a recursive query to the servers in order until one answers. Truncated UDP
+// answers are retried over TCP.
+func (r *Resolver) exchange(ctx context.Context, name dnsmessage.Name, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	id := uint16(rand.N(1 << 16)) //nolint:gosec // query IDs do not need a secure source
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: name, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, server := range r.servers {
+		msg, err := exchangeWith(ctx, "udp", server, packed, id)
+		if err == nil && msg.Truncated {
+			msg, err = exchangeWith(ctx, "tcp", server, packed, id)
+		}
+		if err == nil {
+			return msg, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("%w: %w", ErrResolveFailed, lastErr)
+}
+
+func exchangeWith(ctx context.Context, network, server string, query []byte, id uint16) (*dnsmessage.Message, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var buf []byte
+	if network == "tcp" {
+		out := binary.BigEndian.AppendUint16(nil, uint16(len(query))) //nolint:gosec // queries are far below 64 KiB
+		if _, err := conn.Write(append(out, query...)); err != nil {
+			return nil, err
+		}
+		length := make([]byte, tcpLengthSize)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, binary.BigEndian.Uint16(length))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, maxUDPSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[:n]
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(buf); err != nil {
+		return nil, err
+	}
+	if msg.ID != id || !msg.Response {
+		return nil, fmt.Errorf("unexpected DNS response from %s", server)
+	}
+	return &msg, nil
+}
+
+// canonicalName returns the fully qualified form of a hostname.
+func canonicalName(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if !strings.HasSuffix(host, ".") {
+		host += "."
+	}
+	return host
+}
+
+// isHostname reports whether host is a syntactically valid hostname and not an IP address.
+func isHostname(host string) bool {
+	host = strings.TrimSuffix(strings.TrimSpace(host), ".")
+	if host == "" || len(host) > maxNameLength || net.ParseIP(host) != nil {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > maxLabelLength || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,237 @@
+package resolver_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hibare/Waypoint/internal/config"
+	"github.com/hibare/Waypoint/internal/resolver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// zone answers queries like a recursive resolver, following CNAMEs. Names listed in truncate
+// are answered with the TC bit set over UDP.
+type zone struct {
+	cnames   map[string]string
+	a        map[string][]string
+	aaaa     map[string][]string
+	ptr      map[string]string
+	truncate map[string]bool
+	queries  *atomic.Int32
+}
+
+func (z zone) answer(t *testing.T, packet []byte, udp bool) []byte {
+	t.Helper()
+
+	var query dnsmessage.Message
+	require.NoError(t, query.Unpack(packet))
+	q := query.Questions[0]
+	if z.queries != nil {
+		z.queries.Add(1)
+	}
+
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true},
+		Questions: query.Questions,
+	}
+	header := func(name string, typ dnsmessage.Type) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: typ, Class: dnsmessage.ClassINET, TTL: 300}
+	}
+
+	name := q.Name.String()
+	if q.Type == dnsmessage.TypePTR {
+		if target, ok := z.ptr[name]; ok {
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: header(name, dnsmessage.TypePTR),
+				Body:   &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(target)},
+			})
+		} else {
+			response.RCode = dnsmessage.RCodeNameError
+		}
+		b, err := response.Pack()
+		require.NoError(t, err)
+		return b
+	}
+	if udp && z.truncate[name] {
+		response.Truncated = true
+		b, err := response.Pack()
+		require.NoError(t, err)
+		return b
+	}
+	for target, ok := z.cnames[name]; ok; target, ok = z.cnames[name] {
+		response.Answers = append(response.Answers, dnsmessage.Resource{
+			Header: header(name, dnsmessage.TypeCNAME),
+			Body:   &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(target)},
+		})
+		name = target
+	}
+
+	_, hasA := z.a[name]
+	_, hasAAAA := z.aaaa[name]
+	if !hasA && !hasAAAA && len(response.Answers) == 0 {
+		response.RCode = dnsmessage.RCodeNameError
+	}
+	switch q.Type {
+	case dnsmessage.TypeA:
+		for _, ip := range z.a[name] {
+			h := header(name, dnsmessage.TypeA)
+			h.TTL = 60
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: h,
+				Body:   &dnsmessage.AResource{A: [4]byte(net.ParseIP(ip).To4())},
+			})
+		}
+	case dnsmessage.TypeAAAA:
+		for _, ip := range z.aaaa[name] {
+			response.Answers = append(response.Answers, dnsmessage.Resource{
+				Header: header(name, dnsmessage.TypeAAAA),
+				Body:   &dnsmessage.AAAAResource{AAAA: [16]byte(net.ParseIP(ip).To16())},
+			})
+		}
+	}
+
+	b, err := response.Pack()
+	require.NoError(t, err)
+	return b
+}
+
+// serve starts a DNS server for the zone on UDP and TCP and returns its address.
+func (z zone) serve(t *testing.T) string {
+	t.Helper()
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = udp.Close() })
+
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tcp.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = udp.WriteTo(z.answer(t, buf[:n], true), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			length := make([]byte, 2)
+			if _, err := io.ReadFull(conn, length); err == nil {
+				packet := make([]byte, binary.BigEndian.Uint16(length))
+				if _, err := io.ReadFull(conn, packet); err == nil {
+					b := z.answer(t, packet, false)
+					_, _ = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(b))), b...))
+				}
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	return udp.LocalAddr().String()
+}
+
+func TestResolver_LookupHost(t *testing.T) {
+	server := zone{
+		cnames: map[string]string{
+			"www.example.com.": "cdn.example.net.",
+			"cdn.example.net.": "edge.example.net.",
+		},
+		a: map[string][]string{
+			"edge.example.net.":  {"81.2.69.142", "81.2.69.143", "81.2.69.144"},
+			"large.example.com.": {"81.2.69.142"},
+		},
+		aaaa: map[string][]string{
+			"edge.example.net.": {"2001:db8::1"},
+		},
+		truncate: map[string]bool{"large.example.com.": true},
+	}.serve(t)
+
+	r := resolver.New(&config.ResolverConfig{Server: server, Timeout: time.Second, MaxRecords: 3})
+	ctx := context.Background()
+
+	resolution, err := r.LookupHost(ctx, "WWW.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "www.example.com.", resolution.Name)
+	assert.Equal(t, []string{"cdn.example.net.", "edge.example.net."}, resolution.CNAMEs)
+	require.Len(t, resolution.Records, 3)
+	assert.Equal(t, "81.2.69.142", resolution.Records[0].IP.String())
+	assert.Equal(t, "A", resolution.Records[0].Type)
+	assert.Equal(t, uint32(60), resolution.Records[0].TTL)
+	assert.True(t, resolution.Truncated, "the AAAA record exceeds the record limit")
+
+	// Truncated UDP answers are retried over TCP
+	resolution, err = r.LookupHost(ctx, "large.example.com")
+	require.NoError(t, err)
+	require.Len(t, resolution.Records, 1)
+	assert.Empty(t, resolution.CNAMEs)
+	assert.False(t, resolution.Truncated)
+
+	_, err = r.LookupHost(ctx, "missing.example.com")
+	require.ErrorIs(t, err, resolver.ErrHostNotFound)
+
+	for _, name := range []string{"8.8.8.8", "not a host", "-bad.example.com", ""} {
+		_, err = r.LookupHost(ctx, name)
+		require.ErrorIs(t, err, resolver.ErrInvalidHostname, name)
+	}
+}
+
+func TestResolver_Timeout(t *testing.T) {
+	// A server that never answers
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	r := resolver.New(&config.ResolverConfig{Server: conn.LocalAddr().String(), Timeout: 100 * time.Millisecond, MaxRecords: 1})
+
+	start := time.Now()
+	_, err = r.LookupHost(context.Background(), "example.com")
+	require.ErrorIs(t, err, resolver.ErrResolveFailed)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestResolver_LookupPTR(t *testing.T) {
+	queries := &atomic.Int32{}
+	server := zone{
+		ptr: map[string]string{
+			"142.69.2.81.in-addr.arpa.": "host-81-2-69-142.example.net.",
+			"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.": "v6.example.net.",
+		},
+		queries: queries,
+	}.serve(t)
+
+	r := resolver.New(&config.ResolverConfig{Server: server, Timeout: time.Second, PTRTimeout: time.Second, PTRCacheSize: 10})
+	ctx := context.Background()
+
+	names, err := r.LookupPTR(ctx, net.ParseIP("81.2.69.142"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"host-81-2-69-142.example.net"}, names)
+
+	names, err = r.LookupPTR(ctx, net.ParseIP("2001:db8::1"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v6.example.net"}, names)
+
+	_, err = r.LookupPTR(ctx, net.ParseIP("81.2.69.143"))
+	require.ErrorIs(t, err, resolver.ErrPTRNotFound)
+
+	// Answers, including missing records, are served from the cache
+	_, err = r.LookupPTR(ctx, net.ParseIP("81.2.69.142"))
+	require.NoError(t, err)
+	_, err = r.LookupPTR(ctx, net.ParseIP("81.2.69.143"))
+	require.ErrorIs(t, err, resolver.ErrPTRNotFound)
+	assert.Equal(t, int32(3), queries.Load())
+}
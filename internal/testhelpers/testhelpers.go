@@ -4,8 +4,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"testing"
 
 	"github.com/hibare/Waypoint/internal/constants"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -64,3 +67,27 @@ func LoadTestDB() error {
 
 	return nil
 }
+
+// TestDataPath returns the absolute path of a file in the test_data directory.
+func TestDataPath(t *testing.T, name string) string {
+	t.Helper()
+
+	_, filename, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	return filepath.Join(filepath.Dir(filename), "test_data", name)
+}
+
+// SetupTestDBDir copies the MaxMind test databases into a temporary directory
+// and returns its path, suitable as a data dir for a MaxMind client.
+func SetupTestDBDir(t *testing.T) string {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	for _, name := range []string{"GeoLite2-City.mmdb", "GeoLite2-Country.mmdb", "GeoLite2-ASN.mmdb"} {
+		b, err := os.ReadFile(TestDataPath(t, name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dataDir, name), b, 0600))
+	}
+
+	return dataDir
+}